@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/jobs"
+	"backuprds/internal/service/export"
+	"backuprds/internal/service/source"
+	"backuprds/internal/store"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	aliyunBackupID     string
+	aliyunDestinations []string
+	aliyunForce        bool
+
+	alirdsCmd = &cobra.Command{
+		Use:   "alirds",
+		Short: "阿里云RDS备份",
+	}
+	alirdsLatestCmd = &cobra.Command{
+		Use:   "latest <env>",
+		Short: "查询最新备份的下载链接",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAlirdsLatest,
+	}
+	alirdsExportCmd = &cobra.Command{
+		Use:   "export-s3 <env>",
+		Short: "下载备份并上传到存储目标",
+		Long: `下载指定环境的阿里云RDS备份并上传到实例配置的存储目标，上传结束后退出，结果写入导出历史。
+备份已导出到全部目标时不再上传，使用 --force 强制重新上传`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAlirdsExport,
+	}
+)
+
+func init() {
+	alirdsExportCmd.Flags().StringVar(&aliyunBackupID, "backup-id", "", "导出指定的备份集，为空时导出最新备份")
+	alirdsExportCmd.Flags().StringSliceVar(&aliyunDestinations, "destination", nil, "存储目标，可重复或逗号分隔，为空时使用实例配置的目标")
+	alirdsExportCmd.Flags().BoolVar(&aliyunForce, "force", false, "备份已导出时仍重新上传")
+
+	alirdsCmd.AddCommand(alirdsLatestCmd, alirdsExportCmd)
+	addCLICommand(alirdsCmd)
+}
+
+// aliyunLatestOutput 字段名与 GET /alirds/{env} 的响应一致
+type aliyunLatestOutput struct {
+	Env                 string `json:"env"`
+	BackupID            string `json:"backup_id"`
+	BackupStartTime     string `json:"backup_start_time,omitempty"`
+	Method              string `json:"backup_method"`
+	Status              string `json:"status"`
+	Size                int64  `json:"size"`
+	DownloadURL         string `json:"backup_download_url"`
+	IntranetDownloadURL string `json:"backup_intranet_download_url"`
+}
+
+func runAlirdsLatest(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	src, err := source.Get(store.ProviderAliyun)
+	if err != nil {
+		return failed(err)
+	}
+	instanceConfig, ok := src.Instances(config.GetConfig())[env]
+	if !ok {
+		return failed(export.ErrInvalidEnv)
+	}
+
+	backup, err := src.LatestBackup(ctx, instanceConfig)
+	if err != nil {
+		return failed(err)
+	}
+	if backup == nil || (backup.DownloadURL == "" && backup.IntranetDownloadURL == "") {
+		return failed(export.ErrNoBackup)
+	}
+
+	out := aliyunLatestOutput{
+		Env:                 env,
+		BackupID:            backup.ID,
+		Method:              backup.Type,
+		Status:              backup.Status,
+		Size:                backup.Size,
+		DownloadURL:         backup.DownloadURL,
+		IntranetDownloadURL: backup.IntranetDownloadURL,
+	}
+	if !backup.StartTime.IsZero() {
+		out.BackupStartTime = backup.StartTime.UTC().Format(time.RFC3339)
+	}
+	return render(out, func() {
+		printFields([][2]string{
+			{"Env", out.Env},
+			{"Backup ID", out.BackupID},
+			{"Start Time", out.BackupStartTime},
+			{"Method", out.Method},
+			{"Status", out.Status},
+			{"Size", strconv.FormatInt(out.Size, 10)},
+			{"Download URL", out.DownloadURL},
+			{"Intranet URL", out.IntranetDownloadURL},
+		})
+	})
+}
+
+// aliyunExportOutput 字段名与 POST /alirds/export/s3/{env} 和任务查询的响应一致
+type aliyunExportOutput struct {
+	Env             string                   `json:"env"`
+	BackupID        string                   `json:"backup_id"`
+	BackupStartTime string                   `json:"backup_start_time"`
+	AlreadyExported bool                     `json:"already_exported"`
+	Destination     string                   `json:"destination"`
+	S3Bucket        string                   `json:"s3_bucket"`
+	Region          string                   `json:"region"`
+	S3Key           string                   `json:"s3_key"`
+	Size            int64                    `json:"size"`
+	SHA256          string                   `json:"sha256,omitempty"`
+	MD5             string                   `json:"md5,omitempty"`
+	Destinations    []jobs.DestinationResult `json:"destinations"`
+	Error           string                   `json:"error,omitempty"`
+}
+
+func runAlirdsExport(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	openStore()
+	result, err := export.AliyunToS3(ctx, env, export.AliyunOptions{
+		BackupID:     aliyunBackupID,
+		Destinations: aliyunDestinations,
+		Force:        aliyunForce,
+	})
+	// 部分存储目标失败时仍输出各目标的结果
+	if result == nil {
+		return failed(err)
+	}
+
+	out := aliyunExportOutput{
+		Env:             result.Env,
+		BackupID:        result.BackupID,
+		BackupStartTime: result.BackupStartTime,
+		AlreadyExported: result.AlreadyExported,
+		Destination:     result.Destination,
+		S3Bucket:        result.Bucket,
+		Region:          result.Region,
+		S3Key:           result.S3Key,
+		Size:            result.Size,
+		SHA256:          result.SHA256,
+		MD5:             result.MD5,
+		Destinations:    result.Destinations,
+	}
+	if err != nil {
+		out.Error = err.Error()
+	}
+	if renderErr := render(out, func() {
+		printFields([][2]string{
+			{"Env", out.Env},
+			{"Backup ID", out.BackupID},
+			{"Start Time", out.BackupStartTime},
+			{"Already Exported", strconv.FormatBool(out.AlreadyExported)},
+			{"SHA256", out.SHA256},
+			{"MD5", out.MD5},
+		})
+		fmt.Println()
+		rows := [][]string{{"DESTINATION", "BUCKET", "REGION", "KEY", "SIZE", "RESULT"}}
+		for _, d := range out.Destinations {
+			status := "uploaded"
+			switch {
+			case !d.Succeeded():
+				status = "failed: " + d.Error
+			case d.AlreadyExported:
+				status = "already exported"
+			}
+			rows = append(rows, []string{d.Name, d.Bucket, d.Region, d.Key, strconv.FormatInt(d.Size, 10), status})
+		}
+		printTable(rows)
+	}); renderErr != nil {
+		return renderErr
+	}
+	if err != nil {
+		return failed(err)
+	}
+	return nil
+}
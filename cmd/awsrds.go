@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/service/export"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	awsSnapshotID string
+
+	awsrdsCmd = &cobra.Command{
+		Use:   "awsrds",
+		Short: "AWS RDS快照导出",
+	}
+	awsrdsExportCmd = &cobra.Command{
+		Use:   "export <env>",
+		Short: "启动快照导出任务",
+		Long: `为指定环境启动 RDS 快照导出到 S3 的任务，默认导出最新的自动快照。
+任务启动后立即退出，导出在 AWS 侧异步执行，可通过 awsrds tasks 查询进度`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAwsrdsExport,
+	}
+	awsrdsTasksCmd = &cobra.Command{
+		Use:   "tasks [env]",
+		Short: "查询快照导出任务",
+		Long:  `查询指定环境的快照导出任务，未指定环境时查询全部AWS环境`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runAwsrdsTasks,
+	}
+)
+
+func init() {
+	awsrdsExportCmd.Flags().StringVar(&awsSnapshotID, "snapshot-id", "", "导出指定的快照标识符或ARN，为空时导出最新的自动快照")
+
+	awsrdsCmd.AddCommand(awsrdsExportCmd, awsrdsTasksCmd)
+	addCLICommand(awsrdsCmd)
+}
+
+// awsExportOutput 字段名与 POST /awsrds/export/{env} 的响应一致
+type awsExportOutput struct {
+	Env          string `json:"env"`
+	ExportTaskID string `json:"export_task_id"`
+	SnapshotArn  string `json:"snapshot_arn"`
+	SnapshotID   string `json:"snapshot_id"`
+	InstanceID   string `json:"instance_id"`
+	Region       string `json:"region"`
+	KmsKeyID     string `json:"kms_key_id"`
+	S3BucketName string `json:"s3_bucket_name"`
+	S3Prefix     string `json:"s3_prefix"`
+}
+
+func runAwsrdsExport(cmd *cobra.Command, args []string) error {
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	openStore()
+	result, err := export.AwsSnapshot(ctx, args[0], awsSnapshotID)
+	if err != nil {
+		return failed(err)
+	}
+
+	out := awsExportOutput{
+		Env:          result.Env,
+		ExportTaskID: result.ExportTaskID,
+		SnapshotArn:  result.SnapshotArn,
+		SnapshotID:   result.SnapshotID,
+		InstanceID:   result.InstanceID,
+		Region:       result.Region,
+		KmsKeyID:     result.KmsKeyId,
+		S3BucketName: result.S3BucketName,
+		S3Prefix:     result.S3Prefix,
+	}
+	return render(out, func() {
+		printFields([][2]string{
+			{"Env", out.Env},
+			{"Export Task ID", out.ExportTaskID},
+			{"Snapshot ID", out.SnapshotID},
+			{"Snapshot ARN", out.SnapshotArn},
+			{"Instance ID", out.InstanceID},
+			{"Region", out.Region},
+			{"KMS Key ID", out.KmsKeyID},
+			{"S3 Bucket", out.S3BucketName},
+			{"S3 Prefix", out.S3Prefix},
+		})
+	})
+}
+
+// awsTaskOutput 导出任务及其所属环境
+type awsTaskOutput struct {
+	Env string `json:"env"`
+	aws.ExportTask
+	S3Location string `json:"s3_location"`
+}
+
+func runAwsrdsTasks(cmd *cobra.Command, args []string) error {
+	ctx, cancel := cliContext()
+	defer cancel()
+
+	envs := args
+	if len(envs) == 0 {
+		for env := range config.GetConfig().RDS.Aws.Instances {
+			envs = append(envs, env)
+		}
+		sort.Strings(envs)
+	}
+
+	// 查询全部环境时某个环境失败不影响其他环境的输出，最后以失败退出
+	var firstErr error
+	out := make([]awsTaskOutput, 0)
+	for _, env := range envs {
+		tasks, err := export.AwsExportTasks(ctx, env)
+		if err != nil {
+			if len(args) > 0 {
+				return failed(err)
+			}
+			logger.LogError("Failed to describe export tasks",
+				logger.String("env", env),
+				logger.Error(err))
+			if firstErr == nil {
+				firstErr = fmt.Errorf("env %s: %w", env, err)
+			}
+			continue
+		}
+		for _, t := range tasks {
+			out = append(out, awsTaskOutput{Env: env, ExportTask: t, S3Location: t.S3Location()})
+		}
+	}
+
+	if err := render(out, func() {
+		rows := [][]string{{"ENV", "EXPORT TASK ID", "STATUS", "PROGRESS", "SIZE (GB)", "STARTED", "S3 LOCATION", "FAILURE"}}
+		for _, t := range out {
+			rows = append(rows, []string{
+				t.Env,
+				t.ExportTaskID,
+				t.Status,
+				strconv.Itoa(int(t.PercentProgress)) + "%",
+				strconv.Itoa(int(t.TotalExtractedDataInGB)),
+				formatTime(t.TaskStartTime),
+				t.S3Location,
+				t.FailureCause,
+			})
+		}
+		printTable(rows)
+	}); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return failed(firstErr)
+	}
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/destination"
+	"backuprds/internal/service/export"
+	"backuprds/internal/service/source"
+	"backuprds/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// 命令行子命令的退出码
+const (
+	// exitFailed 云端调用、上传或导出失败
+	exitFailed = 1
+	// exitUsage 参数、环境名称或配置错误
+	exitUsage = 2
+	// exitNotFound 没有找到备份或快照
+	exitNotFound = 3
+)
+
+// 输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var outputFormat string
+
+// exitError 带退出码的错误
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode 命令执行错误对应的进程退出码，cobra 的参数和 flag 错误返回 exitUsage
+func ExitCode(err error) int {
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitUsage
+}
+
+// failed 根据服务层的错误确定退出码
+func failed(err error) error {
+	code := exitFailed
+	switch {
+	case errors.Is(err, export.ErrInvalidEnv),
+		errors.Is(err, export.ErrS3ConfigMissing),
+		errors.Is(err, export.ErrSnapshotNotAvailable),
+		errors.Is(err, destination.ErrUnknownDestination):
+		code = exitUsage
+	case errors.Is(err, export.ErrNoBackup),
+		errors.Is(err, export.ErrNoSnapshot),
+		errors.Is(err, source.ErrBackupNotFound):
+		code = exitNotFound
+	}
+	return &exitError{code: code, err: err}
+}
+
+// addCLICommand 注册直接调用服务层的子命令：添加 --output 参数，执行前加载配置
+func addCLICommand(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "输出格式：table 或 json")
+	cmd.PersistentPreRunE = initCLI
+	rootCmd.AddCommand(cmd)
+}
+
+// initCLI 日志输出到 stderr，加载并校验配置；参数校验已通过，之后的错误不再打印用法
+func initCLI(cmd *cobra.Command, args []string) error {
+	if outputFormat != outputTable && outputFormat != outputJSON {
+		return fmt.Errorf("invalid output format %q, expected table or json", outputFormat)
+	}
+	cmd.SilenceUsage = true
+
+	logger.SetConsoleOutput(os.Stderr)
+	if err := logger.InitFromFile(logConfig); err != nil {
+		return &exitError{code: exitFailed, err: err}
+	}
+	if logLevel != "" {
+		if err := logger.SetLogLevel(logLevel); err != nil {
+			return err
+		}
+	}
+
+	config.SetValidator(validateConfig)
+	if err := config.Load(); err != nil {
+		return &exitError{code: exitUsage, err: err}
+	}
+	return nil
+}
+
+// openStore 打开导出历史，服务正在运行时数据库被占用，此时不记录导出历史
+func openStore() {
+	if err := store.Init(config.GetConfig().Store.Path); err != nil {
+		logger.LogWarn("Store is unavailable, export history will not be recorded",
+			logger.Error(err))
+	}
+}
+
+// cliContext 收到 Ctrl-C 或 SIGTERM 时取消正在执行的操作
+func cliContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// printJSON 以缩进的 JSON 输出到 stdout
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable 以对齐的列输出到 stdout，第一行为表头
+func printTable(rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// printFields 以两列输出单个对象的字段，值为空的字段不输出
+func printFields(fields [][2]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+	}
+	w.Flush()
+}
+
+// render 按 --output 输出结果，table 格式由 table 函数输出
+func render(v any, table func()) error {
+	if outputFormat == outputJSON {
+		return printJSON(v)
+	}
+	table()
+	return nil
+}
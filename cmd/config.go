@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"backuprds/internal/config"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "配置文件管理",
+	}
+	configValidateCmd = &cobra.Command{
+		Use:   "validate [file]",
+		Short: "检查配置文件",
+		Long: `按配置结构检查配置文件：未知的键、重复的键、字段类型、各云厂商的必填项、
+ARN 和 region 格式、实例 ARN 与 region 是否一致、阿里云和AWS之间重复的环境名称，
+以及服务启动时的校验（路径模板、存储目标、阿里云凭证、定时任务）。
+未指定文件时检查 --config 指定的文件，发现问题时列出全部问题并以状态码 1 退出`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runConfigValidate,
+	}
+)
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	file := cfgFile
+	if len(args) > 0 {
+		file = args[0]
+	}
+
+	cfg, issues, err := config.ValidateFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+		os.Exit(1)
+	}
+	// 结构正确时再执行服务启动时的校验，这些校验只报告第一个问题且没有行号
+	if cfg != nil {
+		if err := validateConfig(cfg); err != nil {
+			issues = append(issues, config.Issue{Message: err.Error()})
+		}
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("%s: configuration is valid\n", file)
+		return
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, issue)
+	}
+	fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(issues))
+	os.Exit(1)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/service/destination"
+	"backuprds/internal/store"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var instancesCmd = &cobra.Command{
+	Use:   "instances",
+	Short: "列出配置的实例",
+	Args:  cobra.NoArgs,
+	RunE:  runInstances,
+}
+
+func init() {
+	addCLICommand(instancesCmd)
+}
+
+// instanceOutput 配置中的一个实例
+type instanceOutput struct {
+	Provider     string   `json:"provider"`
+	Env          string   `json:"env"`
+	ID           string   `json:"id"`
+	Region       string   `json:"region"`
+	Destinations []string `json:"destinations,omitempty"`
+	S3Bucket     string   `json:"s3_bucket,omitempty"`
+}
+
+func runInstances(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+
+	out := make([]instanceOutput, 0, len(cfg.RDS.Aliyun.Instances)+len(cfg.RDS.Aws.Instances))
+	for env, instance := range cfg.RDS.Aliyun.Instances {
+		out = append(out, instanceOutput{
+			Provider:     store.ProviderAliyun,
+			Env:          env,
+			ID:           instance.ID,
+			Region:       instance.Region,
+			Destinations: destination.NamesFor(instance),
+		})
+	}
+	for env, instance := range cfg.RDS.Aws.Instances {
+		out = append(out, instanceOutput{
+			Provider: store.ProviderAws,
+			Env:      env,
+			ID:       instance.ID,
+			Region:   instance.Region,
+			S3Bucket: instance.S3BucketName,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Env < out[j].Env
+	})
+
+	return render(out, func() {
+		rows := [][]string{{"PROVIDER", "ENV", "ID", "REGION", "DESTINATION"}}
+		for _, i := range out {
+			dest := i.S3Bucket
+			if len(i.Destinations) > 0 {
+				dest = strings.Join(i.Destinations, ",")
+			}
+			rows = append(rows, []string{i.Provider, i.Env, i.ID, i.Region, dest})
+		}
+		printTable(rows)
+	})
+}
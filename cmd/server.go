@@ -3,6 +3,23 @@ package cmd
 import (
 	"backuprds/internal/config"
 	"backuprds/internal/handlers"
+	"backuprds/internal/jobs"
+	"backuprds/internal/logger"
+	"backuprds/internal/metrics"
+	"backuprds/internal/scheduler"
+	"backuprds/internal/service/aliyun"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/service/destination"
+	"backuprds/internal/service/export"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -11,29 +28,151 @@ import (
 )
 
 func runServer(cmd *cobra.Command, args []string) {
+	config.SetValidator(validateConfig)
 	config.LoadConfig()
 
+	cfg := config.GetConfig()
+	if err := store.Init(cfg.Store.Path); err != nil {
+		logger.LogFatal("Failed to open store",
+			logger.Error(err))
+	}
+	export.RecoverInterruptedExports()
+	jobs.Init(cfg.Jobs.Workers, cfg.Jobs.QueueSize, store.GetStore())
+	export.LoadBackupMetrics(cfg)
+	export.StartAwsTaskWatcher(cfg.RDS.Aws.ExportTask.WatchInterval)
+	if err := scheduler.Start(cfg); err != nil {
+		logger.LogFatal("Failed to start scheduler",
+			logger.Error(err))
+	}
+
+	config.OnReload(applyConfig)
+	config.Watch()
+	watchReloadSignal()
+
 	r := gin.Default()
+	r.Use(metrics.Middleware())
 
 	// 静态文件
 	r.Static("/static", "./static")
 
+	// Prometheus 指标
+	r.GET("/metrics", metrics.Handler())
+
 	// Swagger
 	r.GET("/doc/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API 路由
 	r.GET("/alirds/:env", handlers.BackupHandler)
+	r.GET("/alirds/:env/backups", handlers.ListAliyunBackupsHandler)
 	r.POST("/alirds/export/s3/:env", handlers.AliRDSExportToS3Handler)
 	r.GET("/alirds/s3config", handlers.GetS3ConfigHandler)
 	r.GET("/awsrds/:env", handlers.AwsBackupHandler)
+	r.GET("/awsrds/:env/snapshots", handlers.ListAwsSnapshotsHandler)
 	r.POST("/awsrds/export/:env", handlers.AwsExportHandler)
+	r.GET("/awsrds/export/:env/tasks", handlers.AwsExportTasksHandler)
+	r.GET("/awsrds/export/tasks/:id", handlers.AwsExportTaskHandler)
+	r.DELETE("/awsrds/export/tasks/:id", handlers.CancelAwsExportTaskHandler)
+	r.GET("/backups/:provider/:env", handlers.ListBackupsHandler)
+	r.GET("/backups/:provider/:env/latest", handlers.LatestBackupHandler)
+	r.GET("/backups/:provider/:env/at", handlers.ResolveBackupAtHandler)
+	r.GET("/instances/:provider/:env", handlers.DescribeInstanceHandler)
 	r.GET("/health", handlers.HealthCheckHandler)
 	r.GET("/instances", handlers.GetInstancesHandler)
+	r.GET("/jobs/:id", handlers.GetJobHandler)
+	r.GET("/history", handlers.GetHistoryHandler)
+	r.GET("/schedules", handlers.GetSchedulesHandler)
+	r.POST("/admin/reload", handlers.AdminAuth(), handlers.ReloadConfigHandler)
 
 	// 前端路由
 	r.GET("/", func(c *gin.Context) {
 		c.File("./static/index.html")
 	})
 
-	r.Run(":" + port)
+	serve(r)
+}
+
+// shutdownTimeout 收到退出信号后等待请求和后台任务结束的时间
+const shutdownTimeout = 30 * time.Second
+
+// serve 启动 HTTP 服务，收到 SIGINT/SIGTERM 时停止接收请求并取消正在执行的后台任务，
+// 任务中止时会清理未完成的上传
+func serve(r *gin.Engine) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.LogFatal("Failed to start HTTP server",
+				logger.Error(err))
+		}
+	}()
+
+	<-ctx.Done()
+	logger.LogInfo("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.LogWarn("Failed to stop HTTP server gracefully",
+			logger.Error(err))
+	}
+	if err := scheduler.Shutdown(shutdownCtx); err != nil {
+		logger.LogWarn("Scheduled exports did not stop in time",
+			logger.Error(err))
+	}
+	if err := jobs.GetManager().Shutdown(shutdownCtx); err != nil {
+		logger.LogWarn("Background jobs did not stop in time",
+			logger.Error(err))
+	}
+	if s := store.GetStore(); s != nil {
+		s.Close()
+	}
+}
+
+// validateConfig 启动和重新加载配置时的校验，校验失败的配置不会生效
+func validateConfig(cfg *config.Config) error {
+	if err := export.ValidateKeyTemplates(cfg); err != nil {
+		return fmt.Errorf("invalid S3 key template: %w", err)
+	}
+	if err := destination.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid destination configuration: %w", err)
+	}
+	if err := aliyun.ValidateCredentials(cfg); err != nil {
+		return fmt.Errorf("invalid aliyun credential configuration: %w", err)
+	}
+	if _, err := scheduler.New(cfg); err != nil {
+		return fmt.Errorf("invalid schedule configuration: %w", err)
+	}
+	return nil
+}
+
+// applyConfig 配置重新加载后清空缓存的客户端、移除已删除环境的指标并重建定时任务；
+// 任务队列、历史存储和导出任务检查间隔在启动时使用，修改后需要重启服务
+func applyConfig(old, cfg *config.Config) {
+	aws.ResetClients()
+	aliyun.ResetClients()
+	destination.ResetClients()
+	export.ForgetRemovedEnvs(old, cfg)
+
+	if err := scheduler.Reload(cfg); err != nil {
+		logger.LogError("Failed to reload scheduler",
+			logger.Error(err))
+	}
+
+	if old.Jobs != cfg.Jobs || old.Store != cfg.Store ||
+		old.RDS.Aws.ExportTask.WatchInterval != cfg.RDS.Aws.ExportTask.WatchInterval {
+		logger.LogWarn("Jobs, store and export task watcher settings take effect after restart")
+	}
+}
+
+// watchReloadSignal 收到 SIGHUP 时重新加载配置
+func watchReloadSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			config.Reload(config.TriggerSignal)
+		}
+	}()
 }
@@ -15,9 +15,59 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/reload": {
+            "post": {
+                "description": "重新读取配置文件，校验通过后替换当前配置，返回新增、删除和修改的实例；配置无效时保留当前配置并返回 400。\n只接受本机请求，或携带环境变量 BACKUPRDS_ADMIN_TOKEN 中令牌的请求",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "系统"
+                ],
+                "summary": "重新加载配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cBACKUPRDS_ADMIN_TOKEN\u003e，本机请求不需要",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/alirds/export/s3/{env}": {
             "post": {
-                "description": "获取指定环境的阿里云RDS最新备份并上传到AWS S3",
+                "description": "为指定环境创建后台任务，获取阿里云RDS最新备份(或指定备份集)，下载一次并同时上传到全部存储目标，立即返回任务ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -35,11 +85,40 @@ const docTemplate = `{
                         "name": "env",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "备份集ID，为空时导出最新备份",
+                        "name": "backup_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "备份已导出时仍重新上传",
+                        "name": "force",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "存储目标名称，可重复或逗号分隔，为空时使用实例配置的目标",
+                        "name": "destination",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "备份已导出到全部目标，返回已有的路径",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -54,15 +133,15 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -162,9 +241,196 @@ const docTemplate = `{
                 }
             }
         },
+        "/alirds/{env}/backups": {
+            "get": {
+                "description": "分页获取指定环境在时间范围内的全部备份集，可按状态和备份方式过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "阿里云RDS"
+                ],
+                "summary": "查询阿里云RDS历史备份",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间(RFC3339或2006-01-02)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间(RFC3339或2006-01-02)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "备份状态(Success/Failed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "备份方式(physical/logical/snapshot)",
+                        "name": "method",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/awsrds/export/tasks/{id}": {
+            "get": {
+                "description": "根据导出任务ID查询状态、进度、导出数据量、失败原因和S3路径",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AWS RDS"
+                ],
+                "summary": "查询单个AWS RDS快照导出任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导出任务ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "取消由本服务启动的快照导出任务，任务所属环境必须在配置中",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AWS RDS"
+                ],
+                "summary": "取消AWS RDS快照导出任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导出任务ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/awsrds/export/{env}": {
             "post": {
-                "description": "为指定环境的AWS RDS实例启动快照导出任务",
+                "description": "为指定环境的AWS RDS实例启动快照导出任务，默认导出最新的自动快照",
                 "consumes": [
                     "application/json"
                 ],
@@ -182,6 +448,12 @@ const docTemplate = `{
                         "name": "env",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "快照标识符或ARN，为空时导出最新的自动快照",
+                        "name": "snapshot_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -208,6 +480,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -218,9 +497,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/awsrds/export/{env}/tasks": {
             "get": {
-                "description": "API服务健康状态检查",
+                "description": "查询指定环境的快照导出任务，返回状态、进度、导出数据量、失败原因和S3路径",
                 "consumes": [
                     "application/json"
                 ],
@@ -228,21 +507,776 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "系统"
+                    "AWS RDS"
+                ],
+                "summary": "查询AWS RDS快照导出任务列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "健康检查",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
+        },
+        "/awsrds/{env}/snapshots": {
+            "get": {
+                "description": "分页查询指定环境的自动、手动和共享快照，可按类型、状态和创建时间过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AWS RDS"
+                ],
+                "summary": "查询AWS RDS快照列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "快照类型(automated/manual/shared)，为空时查询全部",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "快照状态，如 available",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间下限(RFC3339或2006-01-02)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间上限(RFC3339或2006-01-02)",
+                        "name": "end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{provider}/{env}": {
+            "get": {
+                "description": "查询指定云厂商和环境在时间范围内的备份(阿里云备份集/AWS快照)，按开始时间倒序返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "备份"
+                ],
+                "summary": "查询备份列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "云厂商(aliyun/aws)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间(RFC3339或2006-01-02)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间(RFC3339或2006-01-02)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只返回成功且可用的备份",
+                        "name": "available",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{provider}/{env}/at": {
+            "get": {
+                "description": "返回指定环境在目标时间点或之前最新的成功备份(阿里云)或可用快照(AWS)，以及相对目标时间的数据丢失窗口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "备份"
+                ],
+                "summary": "查询指定时间点之前最新的备份",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "云厂商(aliyun/aws)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标时间(RFC3339，如2026-09-30T03:00:00Z)",
+                        "name": "time",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/export.PointInTimeBackup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{provider}/{env}/latest": {
+            "get": {
+                "description": "查询指定云厂商和环境的最新备份(阿里云备份集/AWS自动快照)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "备份"
+                ],
+                "summary": "查询最新备份",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "云厂商(aliyun/aws)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/source.Backup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "API服务健康状态检查",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "系统"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/history": {
+            "get": {
+                "description": "按环境、云厂商和起始时间查询导出记录，包含源备份/快照、目标桶和路径、字节数、耗时和结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "查询导出历史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "云厂商(aliyun/aws)",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始时间(RFC3339或2006-01-02)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最大返回条数，默认100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/instances": {
+            "get": {
+                "description": "获取阿里云和AWS的所有实例配置信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "配置"
+                ],
+                "summary": "获取所有实例配置",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/instances/{provider}/{env}": {
+            "get": {
+                "description": "查询指定云厂商和环境的数据库实例信息(引擎、版本、状态)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "备份"
+                ],
+                "summary": "查询实例信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "云厂商(aliyun/aws)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "环境名称",
+                        "name": "env",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/source.Instance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "查询导出任务的状态(queued/downloading/uploading/succeeded/failed)、时间戳、S3路径及错误信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "查询后台任务状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "description": "返回配置的定时导出任务及其下次/上次执行时间、上次执行结果和跳过次数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "查询定时导出任务",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "export.PointInTimeBackup": {
+            "type": "object",
+            "properties": {
+                "backup": {
+                    "$ref": "#/definitions/source.Backup"
+                },
+                "backup_id": {
+                    "type": "string"
+                },
+                "backup_time": {
+                    "type": "string"
+                },
+                "data_loss_seconds": {
+                    "type": "number"
+                },
+                "data_loss_window": {
+                    "description": "DataLossWindow 使用该备份恢复时相对目标时间丢失的数据时长",
+                    "type": "string"
+                },
+                "env": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "target_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.DestinationResult": {
+            "type": "object",
+            "properties": {
+                "already_exported": {
+                    "type": "boolean"
+                },
+                "bucket": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "jobs.Job": {
+            "type": "object",
+            "properties": {
+                "already_exported": {
+                    "type": "boolean"
+                },
+                "backup_id": {
+                    "type": "string"
+                },
+                "backup_start_time": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "destinations": {
+                    "description": "Destinations 各存储目标的上传结果",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jobs.DestinationResult"
+                    }
+                },
+                "env": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "s3_bucket": {
+                    "type": "string"
+                },
+                "s3_key": {
+                    "type": "string"
+                },
+                "s3_region": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/jobs.State"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.State": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "downloading",
+                "uploading",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "StateQueued",
+                "StateDownloading",
+                "StateUploading",
+                "StateSucceeded",
+                "StateFailed"
+            ]
+        },
+        "source.Backup": {
+            "type": "object",
+            "properties": {
+                "arn": {
+                    "description": "Arn AWS快照ARN，阿里云备份为空",
+                    "type": "string"
+                },
+                "available": {
+                    "description": "Available 备份是否成功且可用于恢复或导出",
+                    "type": "boolean"
+                },
+                "detail": {
+                    "description": "Detail 云厂商原始的备份信息"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "instance_id": {
+                    "type": "string"
+                },
+                "intranet_download_url": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "restore_time": {
+                    "description": "RestoreTime 使用该备份恢复后数据所处的时间点",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "description": "StartTime 阿里云为备份开始时间，AWS为快照创建时间",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type 阿里云为备份方式，AWS为快照类型",
+                    "type": "string"
+                }
+            }
+        },
+        "source.Instance": {
+            "type": "object",
+            "properties": {
+                "detail": {},
+                "engine": {
+                    "type": "string"
+                },
+                "engine_version": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
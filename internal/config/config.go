@@ -2,6 +2,11 @@ package config
 
 import (
 	"backuprds/internal/logger"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -9,20 +14,50 @@ type Config struct {
 	RDS struct {
 		Aliyun struct {
 			Instances map[string]InstanceConfig `yaml:"instances"`
-			S3Export  struct {
+			// Credentials 命名的阿里云凭证，实例通过 profile 引用
+			Credentials map[string]AliyunCredentialConfig `yaml:"credentials"`
+			// Endpoints 为例外的 region 指定 RDS 接口地址，未指定的 region 使用 rds.<region>.aliyuncs.com
+			Endpoints map[string]string `yaml:"endpoints"`
+			// VpcEndpoint 使用 rds-vpc.<region>.aliyuncs.com 接口地址，服务部署在阿里云 VPC 内时开启
+			VpcEndpoint bool `yaml:"vpcEndpoint"`
+			// LocalRegion 服务所在的阿里云 region，与实例 region 相同时使用内网下载链接；
+			// 为空时从 ECS 元数据获取，为 none 时始终使用公网下载链接
+			LocalRegion string `yaml:"localRegion"`
+			// KeyTemplate 上传到S3的路径模板
+			KeyTemplate string `yaml:"keyTemplate"`
+			S3Export    struct {
 				Region     string `yaml:"region"`
 				BucketName string `yaml:"bucketname"`
 			} `yaml:"s3export"`
 		} `yaml:"aliyun"`
 		Aws struct {
-			Instances  map[string]InstanceConfig `yaml:"instances"`
-			ExportTask struct {
-				S3Prefix                   string `yaml:"s3prefix"`
-				IamRoleArn                 string `yaml:"iamRoleArn"`
-				ExportTaskIdentifierPrefix string `yaml:"exportTaskIdentifierPrefix"`
+			Instances map[string]InstanceConfig `yaml:"instances"`
+			// KeyTemplate 快照导出的S3前缀模板，为空时使用 exporttask.s3prefix
+			KeyTemplate string `yaml:"keyTemplate"`
+			ExportTask  struct {
+				S3Prefix                   string        `yaml:"s3prefix"`
+				IamRoleArn                 string        `yaml:"iamRoleArn"`
+				ExportTaskIdentifierPrefix string        `yaml:"exportTaskIdentifierPrefix"`
+				WatchInterval              time.Duration `yaml:"watchInterval"`
 			} `yaml:"exporttask"`
 		} `yaml:"aws"`
 	} `yaml:"rds"`
+	// Destinations 备份存储目标，实例通过 destination 引用
+	Destinations map[string]DestinationConfig `yaml:"destinations"`
+	Download     DownloadConfig               `yaml:"download"`
+	// Retry 云端接口调用的重试策略
+	Retry RetryConfig `yaml:"retry"`
+	Jobs  struct {
+		Workers   int `yaml:"workers"`
+		QueueSize int `yaml:"queueSize"`
+	} `yaml:"jobs"`
+	Store struct {
+		Path string `yaml:"path"`
+	} `yaml:"store"`
+	Schedules struct {
+		Groups map[string][]string `yaml:"groups"`
+		Tasks  []ScheduleConfig    `yaml:"tasks"`
+	} `yaml:"schedules"`
 }
 
 type InstanceConfig struct {
@@ -30,31 +65,165 @@ type InstanceConfig struct {
 	Region       string `yaml:"region"`
 	KmsKeyId     string `yaml:"kmsKeyId"`
 	S3BucketName string `yaml:"s3BucketName"`
+	// KeyTemplate 实例的S3路径模板，优先于云厂商的模板
+	KeyTemplate string `yaml:"keyTemplate"`
+	// Destination 备份存储目标名称，为空时使用 rds.aliyun.s3export
+	Destination string `yaml:"destination"`
+	// Destinations 同时上传的多个存储目标，优先于 Destination
+	Destinations []string `yaml:"destinations"`
+	// Profile 访问实例使用的凭证：AWS 为共享配置文件中的 profile，阿里云为 rds.aliyun.credentials 中的名称，
+	// 为空时使用默认凭证链
+	Profile string `yaml:"profile"`
+	// RoleArn 通过 STS AssumeRole 访问实例所在账号的角色，ExternalID/SessionName 为 AssumeRole 参数
+	RoleArn     string `yaml:"roleArn"`
+	ExternalID  string `yaml:"externalId"`
+	SessionName string `yaml:"sessionName"`
+	// IamRoleArn AWS 快照导出任务使用的角色，优先于 exporttask.iamRoleArn
+	IamRoleArn string `yaml:"iamRoleArn"`
+}
+
+// AliyunCredentialConfig 阿里云凭证配置
+type AliyunCredentialConfig struct {
+	// Type 凭证类型：default、access_key、ecs_ram_role、ram_role_arn、oidc_role_arn
+	Type string `yaml:"type"`
+	// AccessKeyEnv/SecretKeyEnv 读取访问密钥的环境变量名（access_key、ram_role_arn），
+	// 为空时使用 ALIBABA_CLOUD_ACCESS_KEY_ID/ALIBABA_CLOUD_ACCESS_KEY_SECRET
+	AccessKeyEnv string `yaml:"accessKeyEnv"`
+	SecretKeyEnv string `yaml:"secretKeyEnv"`
+	// RoleName ECS 实例 RAM 角色名称，为空时自动获取
+	RoleName string `yaml:"roleName"`
+	// RoleArn AssumeRole 或 RRSA 扮演的角色
+	RoleArn     string `yaml:"roleArn"`
+	ExternalID  string `yaml:"externalId"`
+	SessionName string `yaml:"sessionName"`
+	// OIDCProviderArn/OIDCTokenFile RRSA 参数，为空时读取 ACK 注入的环境变量
+	OIDCProviderArn string `yaml:"oidcProviderArn"`
+	OIDCTokenFile   string `yaml:"oidcTokenFile"`
 }
 
-var Cfg Config
+// DestinationConfig 备份存储目标配置
+type DestinationConfig struct {
+	// Type 目标类型：s3、s3compatible、oss、local
+	Type   string `yaml:"type"`
+	Region string `yaml:"region"`
+	Bucket string `yaml:"bucket"`
+	// Endpoint S3 兼容存储或 OSS 的访问地址
+	Endpoint string `yaml:"endpoint"`
+	// PathStyle S3 兼容存储使用 path-style 访问（MinIO 等需要开启）
+	PathStyle bool `yaml:"pathStyle"`
+	// AccessKeyEnv/SecretKeyEnv 读取访问密钥的环境变量名，为空时使用云厂商默认的环境变量；
+	// type 为 s3 且未指定时使用 AWS 默认凭证链
+	AccessKeyEnv string `yaml:"accessKeyEnv"`
+	SecretKeyEnv string `yaml:"secretKeyEnv"`
+	// Profile/RoleArn/ExternalID/SessionName type 为 s3 时的共享配置 profile 和 AssumeRole 参数
+	Profile     string `yaml:"profile"`
+	RoleArn     string `yaml:"roleArn"`
+	ExternalID  string `yaml:"externalId"`
+	SessionName string `yaml:"sessionName"`
+	// Path 本地目录（type 为 local 时）
+	Path string `yaml:"path"`
+}
+
+// DownloadConfig 备份文件下载配置
+type DownloadConfig struct {
+	// MaxRetries 单个文件下载过程中允许的重试次数
+	MaxRetries int `yaml:"maxRetries"`
+	// RetryDelay 首次重试的等待时间，之后按重试次数递增
+	RetryDelay time.Duration `yaml:"retryDelay"`
+	// Connections 并发下载的连接数，<=1 时单连接下载
+	Connections int `yaml:"connections"`
+	// ChunkSizeMB 并发下载时每个分块的大小
+	ChunkSizeMB int64 `yaml:"chunkSizeMB"`
+	// MaxMemoryMB 单个导出任务并发下载时缓存在内存中的分块总大小上限，不含上传的分片缓存
+	MaxMemoryMB int64 `yaml:"maxMemoryMB"`
+}
+
+// RetryConfig 重试策略配置
+type RetryConfig struct {
+	// Default 所有操作的默认策略
+	Default RetryPolicy `yaml:"default"`
+	// Operations 按操作覆盖的策略（describe、export、upload），未设置的字段使用 Default
+	Operations map[string]RetryPolicy `yaml:"operations"`
+}
+
+// RetryPolicy 重试策略，零值或未设置的字段使用默认值
+type RetryPolicy struct {
+	// MaxAttempts 总调用次数（含第一次）
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialDelay 第一次重试前的等待时间，之后每次乘以 Multiplier，不超过 MaxDelay
+	InitialDelay time.Duration `yaml:"initialDelay"`
+	MaxDelay     time.Duration `yaml:"maxDelay"`
+	Multiplier   float64       `yaml:"multiplier"`
+	// Jitter 等待时间的随机抖动比例（0~1），0 表示不加抖动，未设置时使用默认值
+	Jitter *float64 `yaml:"jitter"`
+}
+
+// ScheduleConfig 定时导出任务配置，Envs 和 Group 均为空时导出该云厂商的全部环境
+type ScheduleConfig struct {
+	Name     string   `yaml:"name"`
+	Cron     string   `yaml:"cron"`
+	Provider string   `yaml:"provider"`
+	Envs     []string `yaml:"envs"`
+	Group    string   `yaml:"group"`
+}
+
+// current 当前生效的配置，重新加载时整体替换，已取得的配置不会被修改
+var current atomic.Pointer[Config]
+
+func init() {
+	current.Store(&Config{})
+}
 
 func LoadConfig() {
 	logger.LogInfo("Loading configuration")
 
-	viper.BindEnv("rds.aliyun.s3export.region")
-	viper.BindEnv("rds.aliyun.s3export.bucketname")
-
-	if err := viper.ReadInConfig(); err != nil {
-		logger.LogFatal("Failed to read config file",
+	if err := Load(); err != nil {
+		logger.LogFatal("Failed to load configuration",
 			logger.Error(err))
 	}
 
-	if err := viper.Unmarshal(&Cfg); err != nil {
-		logger.LogFatal("Failed to unmarshal config",
-			logger.Error(err))
+	cfg := GetConfig()
+	logger.LogInfo("Configuration loaded successfully",
+		logger.String("aliyun_region", cfg.RDS.Aliyun.S3Export.Region),
+		logger.String("aliyun_bucket", cfg.RDS.Aliyun.S3Export.BucketName))
+}
+
+// Load 读取并校验配置文件，成功后替换当前配置
+func Load() error {
+	cfg, err := read(viper.GetViper())
+	if err != nil {
+		return err
+	}
+	if validator != nil {
+		if err := validator(cfg); err != nil {
+			return err
+		}
 	}
+	current.Store(cfg)
+	return nil
+}
 
-	logger.LogInfo("Configuration loaded successfully",
-		logger.String("aliyun_region", Cfg.RDS.Aliyun.S3Export.Region),
-		logger.String("aliyun_bucket", Cfg.RDS.Aliyun.S3Export.BucketName))
+// read 读取配置文件并解析为新的配置
+func read(v *viper.Viper) (*Config, error) {
+	v.BindEnv("rds.aliyun.s3export.region")
+	v.BindEnv("rds.aliyun.s3export.bucketname")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	// 文件被截断后重新写入时可能读到空文件
+	if info, err := os.Stat(v.ConfigFileUsed()); err == nil && info.Size() == 0 {
+		return nil, fmt.Errorf("config file %s is empty", v.ConfigFileUsed())
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return &cfg, nil
 }
 
+// GetConfig 返回当前生效的配置，调用方在一次操作中应使用同一个返回值，不能修改
 func GetConfig() *Config {
-	return &Cfg
+	return current.Load()
 }
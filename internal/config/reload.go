@@ -0,0 +1,147 @@
+package config
+
+import (
+	"backuprds/internal/logger"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// 触发重新加载的来源
+const (
+	TriggerWatch  = "watch"
+	TriggerSignal = "signal"
+	TriggerAPI    = "api"
+)
+
+// watchDelay 配置文件最后一次变化后等待的时间
+const watchDelay = time.Second
+
+var (
+	reloadMu  sync.Mutex
+	validator func(*Config) error
+	listeners []func(old, cfg *Config)
+)
+
+// SetValidator 设置配置校验函数，启动和重新加载时校验失败的配置不会生效
+func SetValidator(fn func(*Config) error) {
+	validator = fn
+}
+
+// OnReload 注册配置替换后的回调，old 为替换前的配置
+func OnReload(fn func(old, cfg *Config)) {
+	listeners = append(listeners, fn)
+}
+
+// Diff 重新加载前后实例的变化，实例以 provider/env 表示
+type Diff struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// Reload 重新读取配置文件，校验通过后整体替换当前配置；
+// 读取或校验失败时保留当前配置并返回错误，正在执行的任务继续使用原配置
+func Reload(trigger string) (*Diff, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	v := viper.New()
+	v.SetConfigFile(viper.ConfigFileUsed())
+	cfg, err := read(v)
+	if err == nil && validator != nil {
+		err = validator(cfg)
+	}
+	if err != nil {
+		logger.LogError("Failed to reload configuration, keeping current configuration",
+			logger.String("trigger", trigger),
+			logger.Error(err))
+		return nil, err
+	}
+
+	old := current.Load()
+	diff := diffInstances(old, cfg)
+	if reflect.DeepEqual(old, cfg) {
+		logger.LogInfo("Configuration unchanged",
+			logger.String("trigger", trigger))
+		return diff, nil
+	}
+	current.Store(cfg)
+
+	for _, name := range diff.Added {
+		logger.LogInfo("Instance added", logger.String("instance", name))
+	}
+	for _, name := range diff.Removed {
+		logger.LogInfo("Instance removed", logger.String("instance", name))
+	}
+	for _, name := range diff.Modified {
+		logger.LogInfo("Instance modified", logger.String("instance", name))
+	}
+	for _, fn := range listeners {
+		fn(old, cfg)
+	}
+
+	logger.LogInfo("Configuration reloaded",
+		logger.String("trigger", trigger),
+		logger.Int("added", len(diff.Added)),
+		logger.Int("removed", len(diff.Removed)),
+		logger.Int("modified", len(diff.Modified)))
+	return diff, nil
+}
+
+// Watch 监听配置文件变化并自动重新加载，兼容 Kubernetes ConfigMap 通过符号链接替换文件的方式；
+// 文件停止变化 watchDelay 后才重新加载，避免读到写入了一半的文件
+func Watch() {
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	v := viper.New()
+	v.SetConfigFile(viper.ConfigFileUsed())
+	v.OnConfigChange(func(e fsnotify.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(watchDelay, func() {
+			Reload(TriggerWatch)
+		})
+	})
+	v.WatchConfig()
+
+	logger.LogInfo("Watching configuration file",
+		logger.String("config_file", viper.ConfigFileUsed()))
+}
+
+// diffInstances 比较两份配置中的阿里云和AWS实例
+func diffInstances(old, cfg *Config) *Diff {
+	diff := &Diff{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	compare := func(provider string, before, after map[string]InstanceConfig) {
+		for env, instance := range after {
+			prev, ok := before[env]
+			switch {
+			case !ok:
+				diff.Added = append(diff.Added, provider+"/"+env)
+			case !reflect.DeepEqual(prev, instance):
+				diff.Modified = append(diff.Modified, provider+"/"+env)
+			}
+		}
+		for env := range before {
+			if _, ok := after[env]; !ok {
+				diff.Removed = append(diff.Removed, provider+"/"+env)
+			}
+		}
+	}
+	compare("aliyun", old.RDS.Aliyun.Instances, cfg.RDS.Aliyun.Instances)
+	compare("aws", old.RDS.Aws.Instances, cfg.RDS.Aws.Instances)
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
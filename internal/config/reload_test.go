@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffInstances(t *testing.T) {
+	newConfig := func(aliyun, aws map[string]InstanceConfig) *Config {
+		cfg := &Config{}
+		cfg.RDS.Aliyun.Instances = aliyun
+		cfg.RDS.Aws.Instances = aws
+		return cfg
+	}
+	base := map[string]InstanceConfig{
+		"prod":    {ID: "rm-prod", Region: "cn-hangzhou"},
+		"staging": {ID: "rm-staging", Region: "cn-hangzhou", Destinations: []string{"nfs"}},
+	}
+
+	tests := []struct {
+		name string
+		old  *Config
+		cfg  *Config
+		want *Diff
+	}{
+		{
+			name: "unchanged",
+			old:  newConfig(base, nil),
+			cfg:  newConfig(base, nil),
+			want: &Diff{Added: []string{}, Removed: []string{}, Modified: []string{}},
+		},
+		{
+			name: "empty to configured",
+			old:  &Config{},
+			cfg:  newConfig(base, map[string]InstanceConfig{"prod": {ID: "prod-db"}}),
+			want: &Diff{Added: []string{"aliyun/prod", "aliyun/staging", "aws/prod"}, Removed: []string{}, Modified: []string{}},
+		},
+		{
+			name: "added removed and modified",
+			old:  newConfig(base, map[string]InstanceConfig{"old": {ID: "old-db"}}),
+			cfg: newConfig(map[string]InstanceConfig{
+				"prod":    {ID: "rm-prod", Region: "cn-shanghai"},
+				"staging": {ID: "rm-staging", Region: "cn-hangzhou", Destinations: []string{"nfs", "oss"}},
+				"dev":     {ID: "rm-dev", Region: "cn-hangzhou"},
+			}, nil),
+			want: &Diff{
+				Added:    []string{"aliyun/dev"},
+				Removed:  []string{"aws/old"},
+				Modified: []string{"aliyun/prod", "aliyun/staging"},
+			},
+		},
+		{
+			name: "same env moved between providers",
+			old:  newConfig(map[string]InstanceConfig{"prod": {ID: "rm-prod"}}, nil),
+			cfg:  newConfig(nil, map[string]InstanceConfig{"prod": {ID: "prod-db"}}),
+			want: &Diff{Added: []string{"aws/prod"}, Removed: []string{"aliyun/prod"}, Modified: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffInstances(tt.old, tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffInstances() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// legacyDestination 未给阿里云实例指定存储目标时使用的 rds.aliyun.s3export，与 destination.LegacyName 相同
+const legacyDestination = "s3export"
+
+var (
+	awsRegionPattern    = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+	aliyunRegionPattern = regexp.MustCompile(`^[a-z]{2}-[a-z]+(-[a-z0-9]+)*$`)
+	aliyunIDPattern     = regexp.MustCompile(`^[a-z]+-[a-z0-9]+$`)
+	aliyunRoleArn       = regexp.MustCompile(`^acs:ram::\d+:role/[\w.@-]+$`)
+	dbIdentifierPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,62}$`)
+	rdsArnPattern       = regexp.MustCompile(`^arn:aws[a-z-]*:rds:([a-z0-9-]+):\d{12}:db:([a-zA-Z][a-zA-Z0-9-]*)$`)
+	iamRoleArnPattern   = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]+$`)
+	kmsArnPattern       = regexp.MustCompile(`^arn:aws[a-z-]*:kms:([a-z0-9-]+):\d{12}:(key/[\w-]+|alias/[\w/-]+)$`)
+	kmsKeyIDPattern     = regexp.MustCompile(`^([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32}|alias/[\w/-]+)$`)
+	bucketNamePattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Issue 配置文件中的一个问题
+type Issue struct {
+	// Line 问题所在的行，无法确定位置时为 0
+	Line    int
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidateFile 按配置结构和各云厂商的规则检查配置文件，返回发现的全部问题；
+// 文件无法读取或不是合法的 YAML 时返回错误。结构正确时同时返回解析后的配置，供调用方做进一步校验
+func ValidateFile(path string) (*Config, []Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, []Issue{{Message: "config file is empty"}}, nil
+	}
+
+	s := &schema{keys: make(map[string]int)}
+	s.walk("", doc.Content[0], reflect.TypeOf(Config{}))
+	// 类型错误或重复的键会导致配置无法解析
+	if s.broken {
+		return nil, s.sorted(), nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	cfg, err := read(v)
+	if err != nil {
+		s.add(0, "", err.Error())
+		return nil, s.sorted(), nil
+	}
+	s.checkAliyun(cfg)
+	s.checkAws(cfg)
+	s.checkEnvs(cfg)
+	return cfg, s.sorted(), nil
+}
+
+// schema 检查过程中记录的问题和每个键所在的行
+type schema struct {
+	issues []Issue
+	// keys 键所在的行，键为小写的完整路径
+	keys   map[string]int
+	broken bool
+}
+
+func (s *schema) add(line int, path, format string, args ...any) {
+	s.issues = append(s.issues, Issue{Line: line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// addAt 在路径所在的行记录问题，路径不存在时（如缺少必填项）使用最近的上级路径所在的行
+func (s *schema) addAt(path, format string, args ...any) {
+	s.add(s.line(path), path, format, args...)
+}
+
+func (s *schema) line(path string) int {
+	key := strings.ToLower(path)
+	for key != "" {
+		if line, ok := s.keys[key]; ok {
+			return line
+		}
+		idx := strings.LastIndex(key, ".")
+		if idx < 0 {
+			break
+		}
+		key = key[:idx]
+	}
+	return 0
+}
+
+func (s *schema) sorted() []Issue {
+	sort.SliceStable(s.issues, func(i, j int) bool {
+		return s.issues[i].Line < s.issues[j].Line
+	})
+	return s.issues
+}
+
+// walk 按配置结构体检查节点，结构体字段与键的匹配不区分大小写（与 viper 相同）
+func (s *schema) walk(path string, n *yaml.Node, t reflect.Type) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	// 指针字段表示可选的值，按指向的类型检查
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			fields[strings.ToLower(t.Field(i).Name)] = t.Field(i).Type
+		}
+		s.eachKey(path, n, func(key string, childPath string, keyNode, value *yaml.Node) {
+			ft, ok := fields[strings.ToLower(key)]
+			if !ok {
+				s.add(keyNode.Line, childPath, "unknown key")
+				return
+			}
+			s.walk(childPath, value, ft)
+		})
+	case reflect.Map:
+		s.eachKey(path, n, func(_ string, childPath string, _, value *yaml.Node) {
+			s.walk(childPath, value, t.Elem())
+		})
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			s.invalid(path, n, "expected a list")
+			return
+		}
+		for i, item := range n.Content {
+			s.walk(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())
+		}
+	default:
+		s.scalar(path, n, t)
+	}
+}
+
+// eachKey 遍历映射节点的键，检查重复的键（不区分大小写）
+func (s *schema) eachKey(path string, n *yaml.Node, fn func(key, childPath string, keyNode, value *yaml.Node)) {
+	if n.Kind != yaml.MappingNode {
+		s.invalid(path, n, "expected a mapping")
+		return
+	}
+	seen := make(map[string]int)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, value := n.Content[i], n.Content[i+1]
+		key := keyNode.Value
+		if key == "<<" {
+			continue
+		}
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		if line, ok := seen[strings.ToLower(key)]; ok {
+			s.add(keyNode.Line, childPath, "duplicate key, already defined at line %d", line)
+			s.broken = true
+			continue
+		}
+		seen[strings.ToLower(key)] = keyNode.Line
+		s.keys[strings.ToLower(childPath)] = keyNode.Line
+		fn(key, childPath, keyNode, value)
+	}
+}
+
+// scalar 检查标量的类型
+func (s *schema) scalar(path string, n *yaml.Node, t reflect.Type) {
+	if n.Kind != yaml.ScalarNode {
+		s.invalid(path, n, "expected a single value")
+		return
+	}
+
+	switch kind := t.Kind(); {
+	case t == durationType:
+		// viper 也接受以纳秒为单位的整数
+		if _, err := time.ParseDuration(n.Value); err != nil && !isInteger(n.Value) {
+			s.invalid(path, n, "invalid duration %q, expected a value like \"30s\" or \"5m\"", n.Value)
+		}
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		if !isInteger(n.Value) {
+			s.invalid(path, n, "invalid integer %q", n.Value)
+		}
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		if _, err := strconv.ParseFloat(n.Value, 64); err != nil {
+			s.invalid(path, n, "invalid number %q", n.Value)
+		}
+	case kind == reflect.Bool:
+		if _, err := strconv.ParseBool(n.Value); err != nil {
+			s.invalid(path, n, "invalid boolean %q, expected true or false", n.Value)
+		}
+	}
+}
+
+func isInteger(value string) bool {
+	_, err := strconv.ParseInt(value, 0, 64)
+	return err == nil
+}
+
+func (s *schema) invalid(path string, n *yaml.Node, format string, args ...any) {
+	s.add(n.Line, path, format, args...)
+	s.broken = true
+}
+
+// checkAliyun 检查阿里云实例、凭证和默认的S3导出目标
+func (s *schema) checkAliyun(cfg *Config) {
+	const base = "rds.aliyun"
+	aliyun := cfg.RDS.Aliyun
+
+	usesLegacy := false
+	for _, env := range sortedKeys(aliyun.Instances) {
+		instance := aliyun.Instances[env]
+		path := base + ".instances." + env
+
+		switch {
+		case instance.ID == "":
+			s.addAt(path+".id", "is required")
+		case !aliyunIDPattern.MatchString(instance.ID):
+			s.addAt(path+".id", "invalid aliyun RDS instance ID %q, expected a value like \"rm-bp1xxxx\"", instance.ID)
+		}
+		s.checkRegion(path+".region", instance.Region, aliyunRegionPattern)
+
+		destinations := instance.Destinations
+		if len(destinations) == 0 && instance.Destination != "" {
+			destinations = []string{instance.Destination}
+		}
+		if len(destinations) == 0 {
+			usesLegacy = true
+		}
+		for _, name := range destinations {
+			if name == legacyDestination {
+				usesLegacy = true
+			}
+		}
+	}
+
+	if usesLegacy {
+		if aliyun.S3Export.BucketName == "" {
+			s.addAt(base+".s3export.bucketname", "is required when an instance has no destination")
+		} else if !bucketNamePattern.MatchString(aliyun.S3Export.BucketName) {
+			s.addAt(base+".s3export.bucketname", "invalid S3 bucket name %q", aliyun.S3Export.BucketName)
+		}
+		s.checkRegion(base+".s3export.region", aliyun.S3Export.Region, awsRegionPattern)
+	}
+
+	for _, name := range sortedKeys(aliyun.Credentials) {
+		c := aliyun.Credentials[name]
+		if c.RoleArn != "" && !aliyunRoleArn.MatchString(c.RoleArn) {
+			s.addAt(base+".credentials."+name+".roleArn", "invalid RAM role ARN %q, expected acs:ram::<account>:role/<name>", c.RoleArn)
+		}
+	}
+}
+
+// checkAws 检查AWS实例和快照导出配置：实例 ARN、KMS 密钥 ARN 的 region 必须与 region 一致
+func (s *schema) checkAws(cfg *Config) {
+	const base = "rds.aws"
+	aws := cfg.RDS.Aws
+
+	exportRole := aws.ExportTask.IamRoleArn
+	if exportRole != "" && !iamRoleArnPattern.MatchString(exportRole) {
+		s.addAt(base+".exporttask.iamRoleArn", "invalid IAM role ARN %q", exportRole)
+	}
+
+	for _, env := range sortedKeys(aws.Instances) {
+		instance := aws.Instances[env]
+		path := base + ".instances." + env
+		validRegion := s.checkRegion(path+".region", instance.Region, awsRegionPattern)
+
+		if instance.ID == "" {
+			s.addAt(path+".id", "is required")
+		} else if m := rdsArnPattern.FindStringSubmatch(instance.ID); m != nil {
+			if validRegion && m[1] != instance.Region {
+				s.addAt(path+".id", "instance ARN is in region %s but region is %s", m[1], instance.Region)
+			}
+		} else if strings.HasPrefix(instance.ID, "arn:") {
+			s.addAt(path+".id", "invalid RDS instance ARN %q, expected arn:aws:rds:<region>:<account>:db:<name>", instance.ID)
+		} else if !dbIdentifierPattern.MatchString(instance.ID) {
+			s.addAt(path+".id", "invalid DB instance identifier %q", instance.ID)
+		}
+
+		switch {
+		case instance.KmsKeyId == "":
+			s.addAt(path+".kmsKeyId", "is required for snapshot export")
+		case strings.HasPrefix(instance.KmsKeyId, "arn:"):
+			m := kmsArnPattern.FindStringSubmatch(instance.KmsKeyId)
+			if m == nil {
+				s.addAt(path+".kmsKeyId", "invalid KMS key ARN %q", instance.KmsKeyId)
+			} else if validRegion && m[1] != instance.Region {
+				s.addAt(path+".kmsKeyId", "KMS key is in region %s but the instance is in %s", m[1], instance.Region)
+			}
+		case !kmsKeyIDPattern.MatchString(instance.KmsKeyId):
+			s.addAt(path+".kmsKeyId", "invalid KMS key ID %q, expected a key ID, alias/<name> or key ARN", instance.KmsKeyId)
+		}
+
+		switch {
+		case instance.S3BucketName == "":
+			s.addAt(path+".s3BucketName", "is required for snapshot export")
+		case strings.HasPrefix(instance.S3BucketName, "arn:"):
+			s.addAt(path+".s3BucketName", "expected a bucket name, not an ARN")
+		case !bucketNamePattern.MatchString(instance.S3BucketName):
+			s.addAt(path+".s3BucketName", "invalid S3 bucket name %q", instance.S3BucketName)
+		}
+
+		switch {
+		case instance.IamRoleArn != "" && !iamRoleArnPattern.MatchString(instance.IamRoleArn):
+			s.addAt(path+".iamRoleArn", "invalid IAM role ARN %q", instance.IamRoleArn)
+		case instance.IamRoleArn == "" && exportRole == "":
+			s.addAt(path+".iamRoleArn", "is required when rds.aws.exporttask.iamRoleArn is empty")
+		}
+		if instance.RoleArn != "" && !iamRoleArnPattern.MatchString(instance.RoleArn) {
+			s.addAt(path+".roleArn", "invalid IAM role ARN %q", instance.RoleArn)
+		}
+	}
+}
+
+// checkEnvs 环境名称在阿里云和AWS之间不能重复
+func (s *schema) checkEnvs(cfg *Config) {
+	for _, env := range sortedKeys(cfg.RDS.Aws.Instances) {
+		if _, ok := cfg.RDS.Aliyun.Instances[env]; ok {
+			s.addAt("rds.aws.instances."+env, "env is also defined in rds.aliyun.instances at line %d",
+				s.line("rds.aliyun.instances."+env))
+		}
+	}
+}
+
+// checkRegion 检查必填的 region 格式，返回 region 是否有效
+func (s *schema) checkRegion(path, region string, pattern *regexp.Regexp) bool {
+	if region == "" {
+		s.addAt(path, "is required")
+		return false
+	}
+	if !pattern.MatchString(region) {
+		s.addAt(path, "invalid region %q", region)
+		return false
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const validAws = `rds:
+  aws:
+    exporttask:
+      iamRoleArn: "arn:aws:iam::123456789012:role/export"
+    instances:
+      prod:
+        id: "arn:aws:rds:us-east-1:123456789012:db:prod-db"
+        region: "us-east-1"
+        kmsKeyId: "alias/export"
+        s3BucketName: "backups"
+`
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "valid config",
+			yaml: validAws + `  aliyun:
+    instances:
+      cn:
+        id: "rm-bp1abc"
+        region: "cn-hangzhou"
+        destination: "nfs"
+destinations:
+  nfs:
+    type: local
+    path: /backup
+retry:
+  default:
+    initialDelay: 2s
+    jitter: 0
+`,
+		},
+		{
+			name: "empty file",
+			yaml: "",
+			want: []string{"config file is empty"},
+		},
+		{
+			name: "unknown key",
+			yaml: validAws + `    bucket: "backups"
+`,
+			want: []string{"line 11: rds.aws.bucket: unknown key"},
+		},
+		{
+			name: "invalid scalar types",
+			yaml: `retry:
+  default:
+    maxAttempts: many
+    initialDelay: soon
+    jitter: abc
+`,
+			want: []string{
+				`line 3: retry.default.maxAttempts: invalid integer "many"`,
+				`line 4: retry.default.initialDelay: invalid duration "soon", expected a value like "30s" or "5m"`,
+				`line 5: retry.default.jitter: invalid number "abc"`,
+			},
+		},
+		{
+			name: "duplicate key ignoring case",
+			yaml: `jobs:
+  workers: 2
+  Workers: 3
+`,
+			want: []string{"line 3: jobs.Workers: duplicate key, already defined at line 2"},
+		},
+		{
+			name: "list expected",
+			yaml: `schedules:
+  tasks: nightly
+`,
+			want: []string{"line 2: schedules.tasks: expected a list"},
+		},
+		{
+			name: "aws instance checks",
+			yaml: `rds:
+  aws:
+    instances:
+      prod:
+        id: "arn:aws:rds:eu-west-1:123456789012:db:prod-db"
+        region: "us-east-1"
+        kmsKeyId: "arn:aws:kms:eu-west-1:123456789012:key/abc"
+        s3BucketName: "arn:aws:s3:::backups"
+`,
+			want: []string{
+				"line 4: rds.aws.instances.prod.iamRoleArn: is required when rds.aws.exporttask.iamRoleArn is empty",
+				"line 5: rds.aws.instances.prod.id: instance ARN is in region eu-west-1 but region is us-east-1",
+				"line 7: rds.aws.instances.prod.kmsKeyId: KMS key is in region eu-west-1 but the instance is in us-east-1",
+				"line 8: rds.aws.instances.prod.s3BucketName: expected a bucket name, not an ARN",
+			},
+		},
+		{
+			name: "aliyun instance checks",
+			yaml: `rds:
+  aliyun:
+    instances:
+      cn:
+        region: "Hangzhou"
+    credentials:
+      ram:
+        roleArn: "arn:aws:iam::123456789012:role/x"
+`,
+			want: []string{
+				"line 2: rds.aliyun.s3export.bucketname: is required when an instance has no destination",
+				"line 2: rds.aliyun.s3export.region: is required",
+				"line 4: rds.aliyun.instances.cn.id: is required",
+				`line 5: rds.aliyun.instances.cn.region: invalid region "Hangzhou"`,
+				`line 8: rds.aliyun.credentials.ram.roleArn: invalid RAM role ARN "arn:aws:iam::123456789012:role/x", expected acs:ram::<account>:role/<name>`,
+			},
+		},
+		{
+			name: "env defined for both providers",
+			yaml: validAws + `  aliyun:
+    instances:
+      prod:
+        id: "rm-bp1abc"
+        region: "cn-hangzhou"
+        destination: "nfs"
+`,
+			want: []string{"line 6: rds.aws.instances.prod: env is also defined in rds.aliyun.instances at line 13"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, issues, err := ValidateFile(path)
+			if err != nil {
+				t.Fatalf("ValidateFile() error = %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateFile() issues:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenEnv 管理接口令牌的环境变量，未设置时管理接口只接受本机请求
+const AdminTokenEnv = "BACKUPRDS_ADMIN_TOKEN"
+
+// AdminAuth 管理接口的访问控制：本机直接发起的请求放行，其他请求需携带 Authorization: Bearer <令牌>。
+// 按连接的对端地址判断，不信任 X-Forwarded-For，经反向代理访问时需要使用令牌
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil && ip.IsLoopback() {
+			c.Next()
+			return
+		}
+
+		token := os.Getenv(AdminTokenEnv)
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			logger.LogWarn("Rejected admin request",
+				logger.String("path", c.FullPath()),
+				logger.String("remote_ip", c.RemoteIP()))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access denied"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ReloadConfigHandler godoc
+// @Summary      重新加载配置
+// @Description  重新读取配置文件，校验通过后替换当前配置，返回新增、删除和修改的实例；配置无效时保留当前配置并返回 400。
+// @Description  只接受本机请求，或携带环境变量 BACKUPRDS_ADMIN_TOKEN 中令牌的请求
+// @Tags         系统
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header    string  false  "Bearer <BACKUPRDS_ADMIN_TOKEN>，本机请求不需要"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/reload [post]
+func ReloadConfigHandler(c *gin.Context) {
+	diff, err := config.Reload(config.TriggerAPI)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid configuration, keeping current configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "configuration reloaded",
+		"added":    diff.Added,
+		"removed":  diff.Removed,
+		"modified": diff.Modified,
+	})
+}
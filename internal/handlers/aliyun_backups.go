@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aliyun"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAliyunBackupsHandler godoc
+// @Summary      查询阿里云RDS历史备份
+// @Description  分页获取指定环境在时间范围内的全部备份集，可按状态和备份方式过滤
+// @Tags         阿里云RDS
+// @Accept       json
+// @Produce      json
+// @Param        env     path      string  true   "环境名称"
+// @Param        start   query     string  false  "开始时间(RFC3339或2006-01-02)"
+// @Param        end     query     string  false  "结束时间(RFC3339或2006-01-02)"
+// @Param        status  query     string  false  "备份状态(Success/Failed)"
+// @Param        method  query     string  false  "备份方式(physical/logical/snapshot)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /alirds/{env}/backups [get]
+func ListAliyunBackupsHandler(c *gin.Context) {
+	env := c.Param("env")
+	cfg := config.GetConfig()
+
+	instanceConfig, ok := cfg.RDS.Aliyun.Instances[env]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+		return
+	}
+
+	start, ok := queryTime(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := queryTime(c, "end")
+	if !ok {
+		return
+	}
+
+	filter := aliyun.BackupFilter{
+		Start:  start,
+		End:    end,
+		Status: c.Query("status"),
+		Method: c.Query("method"),
+	}
+	if !filter.Start.IsZero() && !filter.End.IsZero() && filter.End.Before(filter.Start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be later than start"})
+		return
+	}
+	if filter.Method != "" && !isBackupMethod(filter.Method) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid method"})
+		return
+	}
+
+	backups, err := aliyun.ListBackups(c.Request.Context(), instanceConfig.ID, aliyun.TargetFor(instanceConfig), filter)
+	if err != nil {
+		logger.LogError("Failed to list aliyun backups",
+			logger.String("env", env),
+			logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to list backups",
+			"details": err.Error(),
+		})
+		return
+	}
+	if backups == nil {
+		backups = []aliyun.Backup{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"env":         env,
+		"instance_id": instanceConfig.ID,
+		"count":       len(backups),
+		"backups":     backups,
+	})
+}
+
+func isBackupMethod(method string) bool {
+	switch strings.ToLower(method) {
+	case "physical", "logical", "snapshot":
+		return true
+	}
+	return false
+}
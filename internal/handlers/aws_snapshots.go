@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aws"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAwsSnapshotsHandler godoc
+// @Summary      查询AWS RDS快照列表
+// @Description  分页查询指定环境的自动、手动和共享快照，可按类型、状态和创建时间过滤
+// @Tags         AWS RDS
+// @Accept       json
+// @Produce      json
+// @Param        env     path      string  true   "环境名称"
+// @Param        type    query     string  false  "快照类型(automated/manual/shared)，为空时查询全部"
+// @Param        status  query     string  false  "快照状态，如 available"
+// @Param        start   query     string  false  "创建时间下限(RFC3339或2006-01-02)"
+// @Param        end     query     string  false  "创建时间上限(RFC3339或2006-01-02)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /awsrds/{env}/snapshots [get]
+func ListAwsSnapshotsHandler(c *gin.Context) {
+	env := c.Param("env")
+	cfg := config.GetConfig()
+
+	instanceConfig, ok := cfg.RDS.Aws.Instances[env]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+		return
+	}
+
+	start, ok := queryTime(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := queryTime(c, "end")
+	if !ok {
+		return
+	}
+
+	snapshots, err := aws.ListSnapshots(c.Request.Context(), instanceConfig.ID, aws.TargetFor(instanceConfig), aws.SnapshotFilter{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+		Start:  start,
+		End:    end,
+	})
+	if err != nil {
+		if errors.Is(err, aws.ErrInvalidSnapshotType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.LogError("Failed to list AWS snapshots",
+			logger.String("env", env),
+			logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "failed to list snapshots",
+			"details":    err.Error(),
+			"instanceId": instanceConfig.ID,
+			"region":     instanceConfig.Region,
+		})
+		return
+	}
+	if snapshots == nil {
+		snapshots = []aws.Snapshot{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"env":         env,
+		"instance_id": instanceConfig.ID,
+		"region":      instanceConfig.Region,
+		"count":       len(snapshots),
+		"snapshots":   snapshots,
+	})
+}
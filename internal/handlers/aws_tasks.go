@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/service/export"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AwsExportTasksHandler godoc
+// @Summary      查询AWS RDS快照导出任务列表
+// @Description  查询指定环境的快照导出任务，返回状态、进度、导出数据量、失败原因和S3路径
+// @Tags         AWS RDS
+// @Accept       json
+// @Produce      json
+// @Param        env  path      string  true  "环境名称"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /awsrds/export/{env}/tasks [get]
+func AwsExportTasksHandler(c *gin.Context) {
+	env := c.Param("env")
+
+	tasks, err := export.AwsExportTasks(c.Request.Context(), env)
+	if err != nil {
+		if errors.Is(err, export.ErrInvalidEnv) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to describe export tasks",
+			"details": errorDetails(err),
+		})
+		return
+	}
+
+	result := make([]gin.H, 0, len(tasks))
+	for i := range tasks {
+		result = append(result, exportTaskResponse(&tasks[i], env))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"env":   env,
+		"count": len(result),
+		"tasks": result,
+	})
+}
+
+// AwsExportTaskHandler godoc
+// @Summary      查询单个AWS RDS快照导出任务
+// @Description  根据导出任务ID查询状态、进度、导出数据量、失败原因和S3路径
+// @Tags         AWS RDS
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "导出任务ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /awsrds/export/tasks/{id} [get]
+func AwsExportTaskHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	task, env, err := export.FindAwsExportTask(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, aws.ErrExportTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to describe export task",
+			"details": errorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, exportTaskResponse(task, env))
+}
+
+func exportTaskResponse(task *aws.ExportTask, env string) gin.H {
+	return gin.H{
+		"env":                        env,
+		"export_task_id":             task.ExportTaskID,
+		"source_arn":                 task.SourceArn,
+		"status":                     task.Status,
+		"percent_progress":           task.PercentProgress,
+		"total_extracted_data_in_gb": task.TotalExtractedDataInGB,
+		"failure_cause":              task.FailureCause,
+		"warning_message":            task.WarningMessage,
+		"s3_bucket":                  task.S3Bucket,
+		"s3_prefix":                  task.S3Prefix,
+		"s3_location":                task.S3Location(),
+		"snapshot_time":              task.SnapshotTime,
+		"task_start_time":            task.TaskStartTime,
+		"task_end_time":              task.TaskEndTime,
+	}
+}
+
+// errorDetails 返回导出流程错误中云端调用的原始错误信息
+func errorDetails(err error) string {
+	var opErr *export.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// CancelAwsExportTaskHandler godoc
+// @Summary      取消AWS RDS快照导出任务
+// @Description  取消由本服务启动的快照导出任务，任务所属环境必须在配置中
+// @Tags         AWS RDS
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "导出任务ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /awsrds/export/tasks/{id} [delete]
+func CancelAwsExportTaskHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	task, env, err := export.CancelAwsExportTask(c.Request.Context(), id)
+	if err != nil {
+		logger.LogWarn("Failed to cancel export task",
+			logger.String("export_task_id", id),
+			logger.String("env", env),
+			logger.String("client_ip", c.ClientIP()),
+			logger.Error(err))
+
+		switch {
+		case errors.Is(err, export.ErrExportTaskNotOwned), errors.Is(err, aws.ErrExportTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, export.ErrExportTaskEnvRemoved):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "env": env})
+		case errors.Is(err, aws.ErrExportTaskNotCancellable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to cancel export task",
+				"details": errorDetails(err),
+			})
+		}
+		return
+	}
+
+	logger.LogInfo("Export task cancelled",
+		logger.String("export_task_id", id),
+		logger.String("env", env),
+		logger.String("status", task.Status),
+		logger.String("client_ip", c.ClientIP()))
+
+	c.JSON(http.StatusOK, exportTaskResponse(task, env))
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/export"
+	"backuprds/internal/service/source"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// backupSource 根据路径中的 provider 和 env 获取备份来源，失败时写入 400 响应
+func backupSource(c *gin.Context) (source.BackupSource, config.InstanceConfig, bool) {
+	src, instanceConfig, err := export.SourceFor(c.Param("provider"), c.Param("env"))
+	if errors.Is(err, export.ErrInvalidProvider) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid provider",
+			"providers": source.Providers(),
+		})
+		return nil, config.InstanceConfig{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+		return nil, config.InstanceConfig{}, false
+	}
+	return src, instanceConfig, true
+}
+
+// ListBackupsHandler godoc
+// @Summary      查询备份列表
+// @Description  查询指定云厂商和环境在时间范围内的备份(阿里云备份集/AWS快照)，按开始时间倒序返回
+// @Tags         备份
+// @Accept       json
+// @Produce      json
+// @Param        provider   path      string  true   "云厂商(aliyun/aws)"
+// @Param        env        path      string  true   "环境名称"
+// @Param        start      query     string  false  "开始时间(RFC3339或2006-01-02)"
+// @Param        end        query     string  false  "结束时间(RFC3339或2006-01-02)"
+// @Param        available  query     bool    false  "只返回成功且可用的备份"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /backups/{provider}/{env} [get]
+func ListBackupsHandler(c *gin.Context) {
+	src, instanceConfig, ok := backupSource(c)
+	if !ok {
+		return
+	}
+
+	start, ok := queryTime(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := queryTime(c, "end")
+	if !ok {
+		return
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be later than start"})
+		return
+	}
+
+	backups, err := src.ListBackups(c.Request.Context(), instanceConfig, source.ListFilter{
+		Start:         start,
+		End:           end,
+		AvailableOnly: c.Query("available") == "true",
+	})
+	if err != nil {
+		logger.LogError("Failed to list backups",
+			logger.String("provider", src.Name()),
+			logger.String("env", c.Param("env")),
+			logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to list backups",
+			"details": err.Error(),
+		})
+		return
+	}
+	if backups == nil {
+		backups = []source.Backup{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provider":    src.Name(),
+		"env":         c.Param("env"),
+		"instance_id": instanceConfig.ID,
+		"count":       len(backups),
+		"backups":     backups,
+	})
+}
+
+// LatestBackupHandler godoc
+// @Summary      查询最新备份
+// @Description  查询指定云厂商和环境的最新备份(阿里云备份集/AWS自动快照)
+// @Tags         备份
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string  true  "云厂商(aliyun/aws)"
+// @Param        env       path      string  true  "环境名称"
+// @Success      200  {object}  source.Backup
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /backups/{provider}/{env}/latest [get]
+func LatestBackupHandler(c *gin.Context) {
+	src, instanceConfig, ok := backupSource(c)
+	if !ok {
+		return
+	}
+
+	backup, err := src.LatestBackup(c.Request.Context(), instanceConfig)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to get latest backup",
+			"details": err.Error(),
+		})
+		return
+	}
+	if backup == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message":     "no backups found",
+			"instance_id": instanceConfig.ID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, backup)
+}
+
+// DescribeInstanceHandler godoc
+// @Summary      查询实例信息
+// @Description  查询指定云厂商和环境的数据库实例信息(引擎、版本、状态)
+// @Tags         备份
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string  true  "云厂商(aliyun/aws)"
+// @Param        env       path      string  true  "环境名称"
+// @Success      200  {object}  source.Instance
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /instances/{provider}/{env} [get]
+func DescribeInstanceHandler(c *gin.Context) {
+	src, instanceConfig, ok := backupSource(c)
+	if !ok {
+		return
+	}
+
+	instance, err := src.DescribeInstance(c.Request.Context(), instanceConfig)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to describe instance",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, instance)
+}
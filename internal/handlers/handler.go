@@ -1,23 +1,22 @@
 package handlers
 
 import (
+	"backuprds/internal/jobs"
 	"backuprds/internal/logger"
+	"backuprds/internal/retry"
+	"backuprds/internal/service/destination"
+	"backuprds/internal/service/export"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"backuprds/internal/config"
-	"backuprds/internal/service/aliyun"
-	"backuprds/internal/service/aws"
+	"backuprds/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	maxRetries = 3
-	retryDelay = 3 * time.Second
-)
-
 // BackupHandler godoc
 // @Summary      获取阿里云RDS备份下载链接
 // @Description  获取指定环境的阿里云RDS最新备份下载链接
@@ -36,9 +35,8 @@ func BackupHandler(c *gin.Context) {
 		logger.String("env", env),
 		logger.String("client_ip", c.ClientIP()))
 
-	cfg := config.GetConfig()
-	instanceID, ok := cfg.RDS.Aliyun.Instances[env]
-	if !ok {
+	src, instanceConfig, err := export.SourceFor(store.ProviderAliyun, env)
+	if err != nil {
 		logger.LogWarn("Invalid environment requested",
 			logger.String("env", env),
 			logger.String("client_ip", c.ClientIP()))
@@ -46,65 +44,50 @@ func BackupHandler(c *gin.Context) {
 		return
 	}
 
-	var backupURLs map[string]string
-	var err error
-	var lastErr error
-
-	for i := 0; i < maxRetries; i++ {
-		logger.LogInfo("Attempting to get backup URLs",
+	// 查询失败时按 retry.operations.describe 的策略重试，认证失败等永久错误直接返回
+	// retries 为接口调用的次数（含第一次），与重试改为统一策略之前的响应保持一致
+	ctx, attempts := retry.TrackAttempts(c.Request.Context())
+	backup, err := src.LatestBackup(ctx, instanceConfig)
+	if err != nil {
+		logger.LogError("Failed to get backup URLs",
 			logger.String("env", env),
-			logger.Int("attempt", i+1),
-			logger.Int("max_retries", maxRetries))
-
-		backupURLs, err = aliyun.GetLastBackupURLs(instanceID.ID)
-		if err != nil {
-			lastErr = err
-			time.Sleep(retryDelay)
-			continue
-		}
-
-		// 检查是否找到备份
-		if backupURLs["BackupDownloadURL"] == "" && backupURLs["BackupIntranetDownloadURL"] == "" {
-			if i < maxRetries-1 {
-				time.Sleep(retryDelay)
-				continue
-			}
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "no backups found",
-				"retries": i + 1,
-			})
-			return
-		}
-
-		// 找到备份返回结果
-		c.JSON(http.StatusOK, gin.H{
-			"backup_start_time":            backupURLs["BackupStartTime"],
-			"backup_download_url":          backupURLs["BackupDownloadURL"],
-			"backup_intranet_download_url": backupURLs["BackupIntranetDownloadURL"],
-			"retries":                      i + 1,
+			logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to get backup URLs",
+			"details": err.Error(),
+			"retries": attempts(),
 		})
 		return
 	}
 
-	// 所有重试都失败
-	if lastErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to get backup URLs after retries",
-			"details": lastErr.Error(),
-			"retries": maxRetries,
+	// 检查是否找到备份
+	if backup == nil || (backup.DownloadURL == "" && backup.IntranetDownloadURL == "") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "no backups found",
+			"retries": attempts(),
 		})
 		return
 	}
+
+	resp := gin.H{
+		"backup_download_url":          backup.DownloadURL,
+		"backup_intranet_download_url": backup.IntranetDownloadURL,
+		"retries":                      attempts(),
+	}
+	// 备份开始时间无法解析时不返回该字段
+	if !backup.StartTime.IsZero() {
+		resp["backup_start_time"] = backup.StartTime.UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // AwsBackupHandler godoc
 func AwsBackupHandler(c *gin.Context) {
 	env := c.Param("env")
-	cfg := config.GetConfig()
 
 	// 获取实例配置
-	instanceConfig, ok := cfg.RDS.Aws.Instances[env]
-	if !ok {
+	src, instanceConfig, err := export.SourceFor(store.ProviderAws, env)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
 		return
 	}
@@ -112,7 +95,7 @@ func AwsBackupHandler(c *gin.Context) {
 	log.Printf("Fetching snapshots for instance: %s in region: %s", instanceConfig.ID, instanceConfig.Region)
 
 	// 获取最新快照信息
-	snapshotInfo, err := aws.GetLatestSnapshotInfo(instanceConfig.ID, instanceConfig.Region)
+	snapshot, err := src.LatestBackup(c.Request.Context(), instanceConfig)
 	if err != nil {
 		log.Printf("Error getting snapshot info: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -125,7 +108,7 @@ func AwsBackupHandler(c *gin.Context) {
 	}
 
 	// 检查是否找到快照
-	if snapshotInfo["SnapshotArn"] == "" {
+	if snapshot == nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"message":    "no snapshots found",
 			"instanceId": instanceConfig.ID,
@@ -134,27 +117,32 @@ func AwsBackupHandler(c *gin.Context) {
 		return
 	}
 
-	// 返回快照信息
-	c.JSON(http.StatusOK, gin.H{
-		"snapshot_create_time": snapshotInfo["SnapshotCreateTime"],
-		"snapshot_arn":         snapshotInfo["SnapshotArn"],
-		"snapshot_id":          snapshotInfo["SnapshotId"],
-		"status":               snapshotInfo["Status"],
-		"instance_id":          instanceConfig.ID,
-		"region":               instanceConfig.Region,
-	})
+	// 返回快照信息，创建时间未知时不返回该字段
+	resp := gin.H{
+		"snapshot_arn": snapshot.Arn,
+		"snapshot_id":  snapshot.ID,
+		"status":       snapshot.Status,
+		"instance_id":  instanceConfig.ID,
+		"region":       instanceConfig.Region,
+	}
+	if !snapshot.StartTime.IsZero() {
+		resp["snapshot_create_time"] = snapshot.StartTime.UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // AwsExportHandler godoc
 // @Summary      启动AWS RDS快照导出任务
-// @Description  为指定环境的AWS RDS实例启动快照导出任务
+// @Description  为指定环境的AWS RDS实例启动快照导出任务，默认导出最新的自动快照
 // @Tags         AWS RDS
 // @Accept       json
 // @Produce      json
-// @Param        env  path      string  true  "环境名称"
+// @Param        env          path      string  true   "环境名称"
+// @Param        snapshot_id  query     string  false  "快照标识符或ARN，为空时导出最新的自动快照"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /awsrds/export/{env} [post]
 func AwsExportHandler(c *gin.Context) {
@@ -168,59 +156,51 @@ func AwsExportHandler(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Starting export task for instance: %s in region: %s", instanceConfig.ID, instanceConfig.Region)
-
-	// 先获取最新的快照信息
-	snapshotInfo, err := aws.GetLatestSnapshotInfo(instanceConfig.ID, instanceConfig.Region)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "failed to get snapshot info",
-			"details":    err.Error(),
-			"instanceId": instanceConfig.ID,
-			"region":     instanceConfig.Region,
-		})
-		return
-	}
-
-	// 检查是否找到快照
-	if snapshotInfo["SnapshotArn"] == "" {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message":    "no snapshots found",
-			"instanceId": instanceConfig.ID,
-			"region":     instanceConfig.Region,
-		})
-		return
-	}
-
-	// 启动快照导出任务
-	exportTaskID, err := aws.StartRDSSnapshotExport(
-		instanceConfig.ID,
-		snapshotInfo["SnapshotArn"],
-		instanceConfig.Region,
-		cfg.RDS.Aws.ExportTask.IamRoleArn,
-		instanceConfig.KmsKeyId,
-		instanceConfig.S3BucketName,
-		cfg.RDS.Aws.ExportTask.S3Prefix,
-	)
-
+	snapshotID := c.Query("snapshot_id")
+	result, err := export.AwsSnapshot(c.Request.Context(), env, snapshotID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "failed to start export task",
-			"details":    err.Error(),
-			"instanceId": instanceConfig.ID,
-			"region":     instanceConfig.Region,
-		})
+		var opErr *export.OpError
+		switch {
+		case errors.Is(err, export.ErrNoSnapshot):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message":    "no snapshots found",
+				"snapshotId": snapshotID,
+				"instanceId": instanceConfig.ID,
+				"region":     instanceConfig.Region,
+			})
+		case errors.Is(err, export.ErrSnapshotNotAvailable):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      err.Error(),
+				"snapshotId": snapshotID,
+				"instanceId": instanceConfig.ID,
+				"region":     instanceConfig.Region,
+			})
+		case errors.As(err, &opErr):
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":      opErr.Op,
+				"details":    opErr.Err.Error(),
+				"instanceId": instanceConfig.ID,
+				"region":     instanceConfig.Region,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":      err.Error(),
+				"instanceId": instanceConfig.ID,
+				"region":     instanceConfig.Region,
+			})
+		}
 		return
 	}
 
 	// 返回导出任务 ID
 	c.JSON(http.StatusOK, gin.H{
-		"export_task_id": exportTaskID,
-		"snapshot_arn":   snapshotInfo["SnapshotArn"],
-		"instance_id":    instanceConfig.ID,
-		"region":         instanceConfig.Region,
-		"kms_key_id":     instanceConfig.KmsKeyId,
-		"s3_bucket_name": instanceConfig.S3BucketName,
+		"export_task_id": result.ExportTaskID,
+		"snapshot_arn":   result.SnapshotArn,
+		"snapshot_id":    result.SnapshotID,
+		"instance_id":    result.InstanceID,
+		"region":         result.Region,
+		"kms_key_id":     result.KmsKeyId,
+		"s3_bucket_name": result.S3BucketName,
 	})
 }
 
@@ -238,73 +218,84 @@ func HealthCheckHandler(c *gin.Context) {
 
 // AliRDSExportToS3Handler godoc
 // @Summary      将阿里云RDS备份上传到S3
-// @Description  获取指定环境的阿里云RDS最新备份并上传到AWS S3
+// @Description  为指定环境创建后台任务，获取阿里云RDS最新备份(或指定备份集)，下载一次并同时上传到全部存储目标，立即返回任务ID
 // @Tags         阿里云RDS
 // @Accept       json
 // @Produce      json
-// @Param        env  path      string  true  "环境名称"
-// @Success      200  {object}  map[string]interface{}
+// @Param        env          path      string    true   "环境名称"
+// @Param        backup_id    query     string    false  "备份集ID，为空时导出最新备份"
+// @Param        force        query     bool      false  "备份已导出时仍重新上传"
+// @Param        destination  query     []string  false  "存储目标名称，可重复或逗号分隔，为空时使用实例配置的目标"  collectionFormat(multi)
+// @Success      200  {object}  map[string]interface{}  "备份已导出到全部目标，返回已有的路径"
+// @Success      202  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
+// @Failure      503  {object}  map[string]interface{}
 // @Router       /alirds/export/s3/{env} [post]
 func AliRDSExportToS3Handler(c *gin.Context) {
 	env := c.Param("env")
-	cfg := config.GetConfig()
-
-	// 获取实例配置
-	instanceConfig, ok := cfg.RDS.Aliyun.Instances[env]
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
-		return
-	}
-
-	// 获取S3配置信息
-	s3Config := cfg.RDS.Aliyun.S3Export
-	if s3Config.Region == "" || s3Config.BucketName == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "S3 configuration is missing"})
-		return
-	}
-
-	// 获取备份下载链接
-	backupURLs, err := aliyun.GetLastBackupURLs(instanceConfig.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to get backup URLs",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	if backupURLs["BackupDownloadURL"] == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "no backup found"})
-		return
+	backupID := c.Query("backup_id")
+	force := c.Query("force") == "true"
+	destinations := queryList(c, "destination")
+
+	if !force {
+		// 检查失败时仍提交任务，由任务在上传前再次检查
+		existing, err := export.FindAliyunExport(c.Request.Context(), env, backupID, destinations)
+		if err != nil && !errors.Is(err, export.ErrInvalidEnv) && !errors.Is(err, destination.ErrUnknownDestination) {
+			logger.LogWarn("Failed to check existing export",
+				logger.String("env", env),
+				logger.Error(err))
+		}
+		if existing != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"message":          "Backup already exported",
+				"already_exported": true,
+				"backup_id":        existing.BackupID,
+				"destination":      existing.Destination,
+				"s3_bucket":        existing.Bucket,
+				"region":           existing.Region,
+				"s3_key":           existing.S3Key,
+				"destinations":     existing.Destinations,
+			})
+			return
+		}
 	}
 
-	// 执行上传任务并等待完成
-	result, err := aws.UploadBackupToS3(
-		backupURLs["BackupDownloadURL"],
-		cfg.RDS.Aliyun.S3Export.BucketName,
-		cfg.RDS.Aliyun.S3Export.Region,
-		env,
-		backupURLs["BackupStartTime"],
-	)
-
+	job, err := export.SubmitAliyunToS3(env, export.AliyunOptions{
+		BackupID:     backupID,
+		Destinations: destinations,
+		Force:        force,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to upload to S3",
-			"details": err.Error(),
-		})
+		switch {
+		case errors.Is(err, export.ErrInvalidEnv):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+		case errors.Is(err, destination.ErrUnknownDestination):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "unknown destination",
+				"details": err.Error(),
+			})
+		case errors.Is(err, jobs.ErrQueueFull):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "failed to queue export job",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	// 返回成功结果
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Backup upload completed",
-		"s3_bucket": cfg.RDS.Aliyun.S3Export.BucketName,
-		"s3_key":    result.S3Key,
-		"location":  result.Location,
-		"region":    cfg.RDS.Aliyun.S3Export.Region,
+	// 返回任务信息，上传在后台执行
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Backup export job queued",
+		"job_id":       job.ID,
+		"backup_id":    job.BackupID,
+		"state":        job.State,
+		"destination":  job.Destination,
+		"s3_bucket":    job.S3Bucket,
+		"region":       job.S3Region,
+		"destinations": job.Destinations,
 	})
 }
 
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHistoryLimit = 100
+
+// GetHistoryHandler godoc
+// @Summary      查询导出历史
+// @Description  按环境、云厂商和起始时间查询导出记录，包含源备份/快照、目标桶和路径、字节数、耗时和结果
+// @Tags         任务
+// @Accept       json
+// @Produce      json
+// @Param        env       query     string  false  "环境名称"
+// @Param        provider  query     string  false  "云厂商(aliyun/aws)"
+// @Param        since     query     string  false  "起始时间(RFC3339或2006-01-02)"
+// @Param        limit     query     int     false  "最大返回条数，默认100"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /history [get]
+func GetHistoryHandler(c *gin.Context) {
+	filter := store.HistoryFilter{
+		Env:      c.Query("env"),
+		Provider: c.Query("provider"),
+		Limit:    defaultHistoryLimit,
+	}
+
+	if filter.Provider != "" && filter.Provider != store.ProviderAliyun && filter.Provider != store.ProviderAws {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider"})
+		return
+	}
+
+	since, ok := queryTime(c, "since")
+	if !ok {
+		return
+	}
+	filter.Since = since
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	records, err := store.GetStore().ListExports(filter)
+	if err != nil {
+		logger.LogError("Failed to query export history",
+			logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query history", "details": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []*store.ExportRecord{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(records),
+		"records": records,
+	})
+}
+
+// parseTime 解析 RFC3339 时间或日期
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// queryTime 解析时间查询参数，参数为空时返回零值，格式错误时写入 400 响应并返回 false
+func queryTime(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	t, err := parseTime(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name, "details": err.Error()})
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// queryList 读取可重复或逗号分隔的查询参数，忽略空值
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, value := range c.QueryArray(name) {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
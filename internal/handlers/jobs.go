@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"backuprds/internal/jobs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJobHandler godoc
+// @Summary      查询后台任务状态
+// @Description  查询导出任务的状态(queued/downloading/uploading/succeeded/failed)、时间戳、S3路径及错误信息
+// @Tags         任务
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "任务ID"
+// @Success      200  {object}  jobs.Job
+// @Failure      404  {object}  map[string]string
+// @Router       /jobs/{id} [get]
+func GetJobHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	job, ok := jobs.GetManager().Get(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"backuprds/internal/service/export"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveBackupAtHandler godoc
+// @Summary      查询指定时间点之前最新的备份
+// @Description  返回指定环境在目标时间点或之前最新的成功备份(阿里云)或可用快照(AWS)，以及相对目标时间的数据丢失窗口
+// @Tags         备份
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string  true  "云厂商(aliyun/aws)"
+// @Param        env       path      string  true  "环境名称"
+// @Param        time      query     string  true  "目标时间(RFC3339，如2026-09-30T03:00:00Z)"
+// @Success      200  {object}  export.PointInTimeBackup
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /backups/{provider}/{env}/at [get]
+func ResolveBackupAtHandler(c *gin.Context) {
+	provider := c.Param("provider")
+	env := c.Param("env")
+
+	if c.Query("time") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time is required"})
+		return
+	}
+	target, ok := queryTime(c, "time")
+	if !ok {
+		return
+	}
+
+	result, err := export.ResolveBackupAt(c.Request.Context(), provider, env, target)
+	if err != nil {
+		switch {
+		case errors.Is(err, export.ErrInvalidProvider):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, export.ErrInvalidEnv):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment"})
+		case errors.Is(err, export.ErrNoBackup), errors.Is(err, export.ErrNoSnapshot):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":       "no backup found at or before the given time",
+				"target_time": target,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to resolve backup",
+				"details": errorDetails(err),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
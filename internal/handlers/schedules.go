@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"backuprds/internal/scheduler"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSchedulesHandler godoc
+// @Summary      查询定时导出任务
+// @Description  返回配置的定时导出任务及其下次/上次执行时间、上次执行结果和跳过次数
+// @Tags         任务
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /schedules [get]
+func GetSchedulesHandler(c *gin.Context) {
+	s := scheduler.GetScheduler()
+	if s == nil {
+		c.JSON(http.StatusOK, gin.H{"schedules": []scheduler.Status{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": s.List()})
+}
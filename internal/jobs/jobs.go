@@ -0,0 +1,505 @@
+// Package jobs 提供后台导出任务的排队、执行与状态查询
+package jobs
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/metrics"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State 任务状态
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateDownloading State = "downloading"
+	StateUploading   State = "uploading"
+	StateSucceeded   State = "succeeded"
+	StateFailed      State = "failed"
+)
+
+// 任务类型
+const (
+	TypeAliyunExportS3 = "alirds-export-s3"
+)
+
+const (
+	defaultWorkers   = 2
+	defaultQueueSize = 100
+	// 内存和持久化存储中最多保留的已结束任务数
+	maxFinishedJobs = 1000
+	// 每结束多少个任务清理一次持久化存储
+	pruneEvery = 100
+)
+
+// ErrQueueFull 任务队列已满
+var ErrQueueFull = errors.New("job queue is full")
+
+// Job 后台任务记录
+type Job struct {
+	ID              string     `json:"id"`
+	Type            string     `json:"type"`
+	Env             string     `json:"env"`
+	State           State      `json:"state"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	BackupID        string     `json:"backup_id,omitempty"`
+	BackupStartTime string     `json:"backup_start_time,omitempty"`
+	Destination     string     `json:"destination,omitempty"`
+	S3Bucket        string     `json:"s3_bucket,omitempty"`
+	S3Region        string     `json:"s3_region,omitempty"`
+	S3Key           string     `json:"s3_key,omitempty"`
+	Location        string     `json:"location,omitempty"`
+	Size            int64      `json:"size,omitempty"`
+	SHA256          string     `json:"sha256,omitempty"`
+	MD5             string     `json:"md5,omitempty"`
+	AlreadyExported bool       `json:"already_exported,omitempty"`
+	Error           string     `json:"error,omitempty"`
+
+	// Destinations 各存储目标的上传结果
+	Destinations []DestinationResult `json:"destinations,omitempty"`
+}
+
+// DestinationResult 单个存储目标的上传结果
+type DestinationResult struct {
+	Name            string `json:"name"`
+	Bucket          string `json:"bucket,omitempty"`
+	Region          string `json:"region,omitempty"`
+	Key             string `json:"key,omitempty"`
+	Location        string `json:"location,omitempty"`
+	Size            int64  `json:"size,omitempty"`
+	AlreadyExported bool   `json:"already_exported,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Succeeded 该目标是否已保存备份
+func (r DestinationResult) Succeeded() bool {
+	return r.Error == ""
+}
+
+// Finished 任务是否已结束
+func (j *Job) Finished() bool {
+	return j.State == StateSucceeded || j.State == StateFailed
+}
+
+// Task 传递给任务函数，用于在执行过程中更新任务记录
+type Task struct {
+	id string
+	m  *Manager
+}
+
+// ID 返回任务ID
+func (t *Task) ID() string {
+	return t.id
+}
+
+// Context 任务执行使用的 context，服务关闭时取消
+func (t *Task) Context() context.Context {
+	return t.m.ctx
+}
+
+// SetState 更新任务状态
+func (t *Task) SetState(state State) {
+	t.Update(func(j *Job) { j.State = state })
+}
+
+// Update 修改任务记录
+func (t *Task) Update(fn func(j *Job)) {
+	t.m.update(t.id, fn)
+}
+
+// Func 任务执行函数
+type Func func(t *Task) error
+
+type queued struct {
+	id      string
+	jobType string
+	fn      Func
+}
+
+// Persister 持久化任务记录，使任务状态在重启后仍可查询
+type Persister interface {
+	SaveJob(job *Job) error
+	GetJob(id string) (*Job, error)
+	ListJobs() ([]*Job, error)
+	// PruneJobs 只保留最近结束的 keep 个任务记录
+	PruneJobs(keep int) (int, error)
+}
+
+// Manager 管理任务队列和工作协程
+type Manager struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	done      map[string]chan struct{}
+	queue     chan queued
+	persister Persister
+	// saveMu 保证任务记录按修改顺序写入持久化存储，写入时不持有 mu，查询不必等待磁盘
+	saveMu sync.Mutex
+	// finished 上次清理持久化存储后结束的任务数，由 mu 保护
+	finished int
+
+	// ctx 在 Shutdown 时取消，正在执行的任务随之中止
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+var manager *Manager
+
+// Init 初始化全局任务管理器并启动工作协程，persister 可为 nil
+func Init(workers, queueSize int, persister Persister) {
+	manager = NewManager(workers, queueSize, persister)
+}
+
+// GetManager 返回全局任务管理器
+func GetManager() *Manager {
+	return manager
+}
+
+// NewManager 创建任务管理器并启动工作协程
+func NewManager(workers, queueSize int, persister Persister) *Manager {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		jobs:      make(map[string]*Job),
+		done:      make(map[string]chan struct{}),
+		queue:     make(chan queued, queueSize),
+		persister: persister,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	m.recoverInterrupted()
+
+	m.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+
+	logger.LogInfo("Job manager started",
+		logger.Int("workers", workers),
+		logger.Int("queue_size", queueSize))
+	return m
+}
+
+// Submit 创建任务并放入队列，返回任务记录的副本
+func (m *Manager) Submit(jobType, env string, init func(j *Job), fn Func) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate job id: %v", err)
+	}
+
+	job := &Job{
+		ID:        id,
+		Type:      jobType,
+		Env:       env,
+		State:     StateQueued,
+		CreatedAt: time.Now(),
+	}
+	if init != nil {
+		init(job)
+	}
+
+	// 入队成功后才持久化，队列已满的任务不留下记录；持有 saveMu 使工作协程的状态更新在此之后写入
+	m.saveMu.Lock()
+	m.mu.Lock()
+	m.jobs[id] = job
+	m.done[id] = make(chan struct{})
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- queued{id: id, jobType: jobType, fn: fn}:
+		m.save(job.clone())
+		m.saveMu.Unlock()
+	default:
+		m.mu.Lock()
+		delete(m.jobs, id)
+		close(m.done[id])
+		delete(m.done, id)
+		m.mu.Unlock()
+		m.saveMu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	logger.LogInfo("Job queued",
+		logger.String("job_id", id),
+		logger.String("type", jobType),
+		logger.String("env", env))
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return job.clone(), nil
+}
+
+// Get 查询任务，内存中不存在时从持久化存储读取，返回任务记录的副本
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	job, ok := m.jobs[id]
+	if ok {
+		copied := job.clone()
+		m.mu.RUnlock()
+		return copied, true
+	}
+	m.mu.RUnlock()
+
+	if m.persister == nil {
+		return nil, false
+	}
+	job, err := m.persister.GetJob(id)
+	if err != nil {
+		logger.LogError("Failed to load job",
+			logger.String("job_id", id),
+			logger.Error(err))
+		return nil, false
+	}
+	return job, job != nil
+}
+
+// Wait 阻塞直到任务结束或 ctx 取消，返回任务记录的副本
+func (m *Manager) Wait(ctx context.Context, id string) (*Job, error) {
+	m.mu.RLock()
+	done, ok := m.done[id]
+	m.mu.RUnlock()
+
+	if ok {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	job, found := m.Get(id)
+	if !found {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	return job, nil
+}
+
+// update 修改内存中的任务记录，并在释放 mu 后写入持久化存储
+func (m *Manager) update(id string, fn func(j *Job)) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if ok {
+		fn(job)
+		job = job.clone()
+	}
+	m.mu.Unlock()
+
+	if ok {
+		m.save(job)
+	}
+}
+
+// save 持久化任务记录，调用方不能持有 mu
+func (m *Manager) save(job *Job) {
+	if m.persister == nil {
+		return
+	}
+	if err := m.persister.SaveJob(job); err != nil {
+		logger.LogError("Failed to persist job",
+			logger.String("job_id", job.ID),
+			logger.Error(err))
+	}
+}
+
+// recoverInterrupted 将上次进程退出时未结束的任务标记为失败
+func (m *Manager) recoverInterrupted() {
+	if m.persister == nil {
+		return
+	}
+
+	list, err := m.persister.ListJobs()
+	if err != nil {
+		logger.LogError("Failed to load persisted jobs",
+			logger.Error(err))
+		return
+	}
+
+	now := time.Now()
+	for _, job := range list {
+		if job.Finished() {
+			continue
+		}
+		job.State = StateFailed
+		job.FinishedAt = &now
+		job.Error = "job interrupted by server restart"
+		m.save(job)
+
+		logger.LogWarn("Marked interrupted job as failed",
+			logger.String("job_id", job.ID),
+			logger.String("env", job.Env))
+	}
+	m.prunePersisted()
+}
+
+// Shutdown 取消正在执行的任务并停止工作协程，等待任务结束或 ctx 取消；
+// 队列中尚未开始的任务保持排队状态，下次启动时标记为失败
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		logger.LogInfo("Job manager stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) worker() {
+	defer m.workers.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case q := <-m.queue:
+			if m.ctx.Err() != nil {
+				return
+			}
+			m.run(q)
+		}
+	}
+}
+
+func (m *Manager) run(q queued) {
+	now := time.Now()
+	m.update(q.id, func(j *Job) {
+		j.State = StateDownloading
+		j.StartedAt = &now
+	})
+
+	running := metrics.JobStarted(q.jobType)
+	err := m.call(q)
+	running()
+
+	finished := time.Now()
+	m.update(q.id, func(j *Job) {
+		j.FinishedAt = &finished
+		if err != nil {
+			j.State = StateFailed
+			j.Error = err.Error()
+		} else {
+			j.State = StateSucceeded
+		}
+	})
+
+	m.mu.Lock()
+	close(m.done[q.id])
+	delete(m.done, q.id)
+	m.mu.Unlock()
+
+	if err != nil {
+		logger.LogError("Job failed",
+			logger.String("job_id", q.id),
+			logger.Error(err),
+			logger.Duration("duration", finished.Sub(now)))
+	} else {
+		logger.LogInfo("Job succeeded",
+			logger.String("job_id", q.id),
+			logger.Duration("duration", finished.Sub(now)))
+	}
+
+	m.prune()
+}
+
+// call 执行任务函数，并将 panic 转换为任务失败
+func (m *Manager) call(q queued) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return q.fn(&Task{id: q.id, m: m})
+}
+
+// prune 清理内存中超出保留数量的已结束任务，每结束 pruneEvery 个任务清理一次持久化存储
+func (m *Manager) prune() {
+	m.mu.Lock()
+	m.finished++
+	prunePersisted := m.finished >= pruneEvery
+	if prunePersisted {
+		m.finished = 0
+	}
+	m.pruneMemory()
+	m.mu.Unlock()
+
+	if prunePersisted {
+		m.prunePersisted()
+	}
+}
+
+// pruneMemory 清理内存中超出保留数量的已结束任务，调用方需持有 mu
+func (m *Manager) pruneMemory() {
+	var finished []*Job
+	for _, job := range m.jobs {
+		if job.Finished() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, job.ID)
+	}
+}
+
+// prunePersisted 清理持久化存储中超出保留数量的已结束任务
+func (m *Manager) prunePersisted() {
+	if m.persister == nil {
+		return
+	}
+	n, err := m.persister.PruneJobs(maxFinishedJobs)
+	if err != nil {
+		logger.LogError("Failed to prune persisted jobs",
+			logger.Error(err))
+		return
+	}
+	if n > 0 {
+		logger.LogDebug("Pruned persisted jobs",
+			logger.Int("count", n))
+	}
+}
+
+// clone 复制任务记录，切片字段不与原记录共享
+func (j *Job) clone() *Job {
+	copied := *j
+	copied.Destinations = slices.Clone(j.Destinations)
+	return &copied
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
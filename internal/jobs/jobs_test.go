@@ -0,0 +1,266 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memPersister 内存中的 Persister
+type memPersister struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	prunes int
+}
+
+func newMemPersister(seed ...*Job) *memPersister {
+	p := &memPersister{jobs: make(map[string]*Job)}
+	for _, job := range seed {
+		p.jobs[job.ID] = job.clone()
+	}
+	return p
+}
+
+func (p *memPersister) SaveJob(job *Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs[job.ID] = job.clone()
+	return nil
+}
+
+func (p *memPersister) GetJob(id string) (*Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return job.clone(), nil
+}
+
+func (p *memPersister) ListJobs() ([]*Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	list := make([]*Job, 0, len(p.jobs))
+	for _, job := range p.jobs {
+		list = append(list, job.clone())
+	}
+	return list, nil
+}
+
+func (p *memPersister) PruneJobs(int) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prunes++
+	return 0, nil
+}
+
+func shutdown(t *testing.T, m *Manager) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+}
+
+func TestManagerRunsJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        Func
+		wantState State
+		wantError string
+	}{
+		{
+			name:      "success",
+			fn:        func(t *Task) error { t.Update(func(j *Job) { j.Size = 42 }); return nil },
+			wantState: StateSucceeded,
+		},
+		{
+			name:      "failure",
+			fn:        func(*Task) error { return errors.New("upload failed") },
+			wantState: StateFailed,
+			wantError: "upload failed",
+		},
+		{
+			name:      "panic",
+			fn:        func(*Task) error { panic("nil destination") },
+			wantState: StateFailed,
+			wantError: "job panicked: nil destination",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newMemPersister()
+			m := NewManager(1, 1, p)
+			defer shutdown(t, m)
+
+			job, err := m.Submit(TypeAliyunExportS3, "prod", func(j *Job) { j.BackupID = "b1" }, tt.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State != StateQueued || job.BackupID != "b1" {
+				t.Errorf("submitted job = %+v", job)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			got, err := m.Wait(ctx, job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.State != tt.wantState || got.Error != tt.wantError {
+				t.Errorf("job state = %s, error = %q, want %s, %q", got.State, got.Error, tt.wantState, tt.wantError)
+			}
+			if got.StartedAt == nil || got.FinishedAt == nil {
+				t.Errorf("job timestamps not set: %+v", got)
+			}
+
+			persisted, _ := p.GetJob(job.ID)
+			if persisted == nil || persisted.State != tt.wantState || persisted.Error != tt.wantError {
+				t.Errorf("persisted job = %+v", persisted)
+			}
+		})
+	}
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	p := newMemPersister()
+	m := NewManager(1, 1, p)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	running, err := m.Submit(TypeAliyunExportS3, "prod", nil, func(*Task) error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	noop := func(*Task) error { return nil }
+	queued, err := m.Submit(TypeAliyunExportS3, "prod", nil, noop)
+	if err != nil {
+		t.Fatalf("second Submit() error = %v", err)
+	}
+	rejected, err := m.Submit(TypeAliyunExportS3, "prod", nil, noop)
+	if !errors.Is(err, ErrQueueFull) || rejected != nil {
+		t.Fatalf("third Submit() = %v, %v, want ErrQueueFull", rejected, err)
+	}
+
+	// 被拒绝的任务不能留在内存或持久化存储中
+	p.mu.Lock()
+	persisted := len(p.jobs)
+	p.mu.Unlock()
+	if persisted != 2 {
+		t.Errorf("persisted %d jobs, want 2", persisted)
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range []string{running.ID, queued.ID} {
+		job, err := m.Wait(ctx, id)
+		if err != nil || job.State != StateSucceeded {
+			t.Errorf("job %s = %+v, %v", id, job, err)
+		}
+	}
+	shutdown(t, m)
+}
+
+func TestRecoverInterrupted(t *testing.T) {
+	finishedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	p := newMemPersister(
+		&Job{ID: "queued", State: StateQueued},
+		&Job{ID: "downloading", State: StateDownloading},
+		&Job{ID: "uploading", State: StateUploading},
+		&Job{ID: "succeeded", State: StateSucceeded, FinishedAt: &finishedAt},
+		&Job{ID: "failed", State: StateFailed, FinishedAt: &finishedAt, Error: "upload failed"},
+	)
+	m := NewManager(1, 1, p)
+	defer shutdown(t, m)
+
+	tests := []struct {
+		id        string
+		wantState State
+		wantError string
+	}{
+		{"queued", StateFailed, "job interrupted by server restart"},
+		{"downloading", StateFailed, "job interrupted by server restart"},
+		{"uploading", StateFailed, "job interrupted by server restart"},
+		{"succeeded", StateSucceeded, ""},
+		{"failed", StateFailed, "upload failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			job, ok := m.Get(tt.id)
+			if !ok {
+				t.Fatalf("job %s not found", tt.id)
+			}
+			if job.State != tt.wantState || job.Error != tt.wantError || job.FinishedAt == nil {
+				t.Errorf("job = %+v, want state %s, error %q", job, tt.wantState, tt.wantError)
+			}
+		})
+	}
+	if p.prunes != 1 {
+		t.Errorf("PruneJobs called %d times, want 1", p.prunes)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	p := newMemPersister()
+	m := NewManager(1, 1, p)
+
+	started := make(chan struct{})
+	running, err := m.Submit(TypeAliyunExportS3, "prod", nil, func(t *Task) error {
+		close(started)
+		<-t.Context().Done()
+		return t.Context().Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, err := m.Submit(TypeAliyunExportS3, "prod", nil, func(*Task) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown(t, m)
+
+	job, _ := m.Get(running.ID)
+	if job.State != StateFailed || job.Error != context.Canceled.Error() {
+		t.Errorf("running job = %+v, want failed with %q", job, context.Canceled)
+	}
+	// 队列中的任务保持排队状态，下次启动时标记为失败
+	job, _ = p.GetJob(queued.ID)
+	if job == nil || job.State != StateQueued {
+		t.Errorf("queued job = %+v, want queued", job)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	m := NewManager(1, 1, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := m.Submit(TypeAliyunExportS3, "prod", nil, func(*Task) error {
+		close(started)
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"backuprds/internal/metrics"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -22,7 +23,16 @@ func NewWecomHook(levels []string, webhookURL string) *WecomHook {
 	}
 }
 
+// Fire 发送告警消息，发送失败计入 wecom_hook_failures_total
 func (h *WecomHook) Fire(entry zapcore.Entry) error {
+	err := h.fire(entry)
+	if err != nil {
+		metrics.IncWecomFailure()
+	}
+	return err
+}
+
+func (h *WecomHook) fire(entry zapcore.Entry) error {
 	// 检查是否需要处理该级别的日志
 	levelStr := strings.ToLower(entry.Level.String())
 	shouldProcess := false
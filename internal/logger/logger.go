@@ -19,8 +19,17 @@ var (
 	logger *zap.Logger
 	once   sync.Once
 	level  zap.AtomicLevel
+
+	// consoleOutput 控制台日志的输出位置
+	consoleOutput zapcore.WriteSyncer = os.Stdout
 )
 
+// SetConsoleOutput 设置控制台日志的输出位置，之后调用 InitFromFile 时生效；
+// 命令行子命令将日志输出到 stderr，避免与命令的输出混在一起
+func SetConsoleOutput(w zapcore.WriteSyncer) {
+	consoleOutput = w
+}
+
 // Field 字段构造函数
 type Field = zapcore.Field
 
@@ -123,7 +132,7 @@ func InitFromFile(configPath string) error {
 		consoleEncoder := getEncoder(cfg.Format, encoderConfig)
 		consoleCore := zapcore.NewCore(
 			consoleEncoder,
-			zapcore.AddSync(os.Stdout),
+			consoleOutput,
 			level,
 		)
 		cores = append(cores, consoleCore)
@@ -0,0 +1,226 @@
+// Package metrics 定义 /metrics 暴露的 Prometheus 指标，各模块通过这里的函数记录，不直接引用指标对象
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "backuprds"
+
+// 云端调用结果
+const (
+	OutcomeSuccess  = "success"
+	OutcomeError    = "error"
+	OutcomeCanceled = "canceled"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	cloudCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cloud_api_calls_total",
+		Help:      "Cloud API calls by provider, operation and outcome, each retry attempt counted separately.",
+	}, []string{"provider", "operation", "outcome"})
+
+	cloudDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cloud_api_call_duration_seconds",
+		Help:      "Cloud API call latency by provider and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "operation"})
+
+	uploadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes of backups uploaded to each destination.",
+	}, []string{"destination"})
+
+	uploadThroughput = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_throughput_bytes_per_second",
+		Help:      "Average throughput of each completed backup upload.",
+		// 1 MiB/s ~ 2 GiB/s
+		Buckets: prometheus.ExponentialBuckets(1<<20, 2, 12),
+	}, []string{"destination"})
+
+	exportDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "export_duration_seconds",
+		Help:      "Duration of backup exports by provider, env and outcome.",
+		// 1 分钟 ~ 17 小时
+		Buckets: prometheus.ExponentialBuckets(60, 2, 11),
+	}, []string{"provider", "env", "outcome"})
+
+	retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Retried cloud calls by retry operation.",
+	}, []string{"operation"})
+
+	wecomFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wecom_hook_failures_total",
+		Help:      "WeCom alert messages that failed to send.",
+	})
+
+	jobsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "jobs_in_flight",
+		Help:      "Background jobs currently running by type.",
+	}, []string{"type"})
+
+	backupAges = newBackupAgeCollector()
+)
+
+func init() {
+	prometheus.MustRegister(backupAges)
+}
+
+// Handler /metrics 接口
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware 记录 HTTP 请求数和耗时，route 为注册的路由模板，未匹配的请求记为 unmatched，避免标签基数随路径增长
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveCloudCall 记录一次云端接口调用，operation 为服务和接口名称，如 RDS.DescribeBackups
+func ObserveCloudCall(provider, operation string, start time.Time, err error) {
+	outcome := OutcomeSuccess
+	switch {
+	case errors.Is(err, context.Canceled):
+		outcome = OutcomeCanceled
+	case err != nil:
+		outcome = OutcomeError
+	}
+	cloudCalls.WithLabelValues(provider, operation, outcome).Inc()
+	cloudDuration.WithLabelValues(provider, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveUpload 记录上传到存储目标完成的字节数和平均速度
+func ObserveUpload(destination string, bytes int64, elapsed time.Duration) {
+	uploadBytes.WithLabelValues(destination).Add(float64(bytes))
+	if elapsed > 0 {
+		uploadThroughput.WithLabelValues(destination).Observe(float64(bytes) / elapsed.Seconds())
+	}
+}
+
+// ObserveExport 记录一次导出的耗时，outcome 与导出历史的结果一致
+func ObserveExport(provider, env, outcome string, elapsed time.Duration) {
+	exportDuration.WithLabelValues(provider, env, outcome).Observe(elapsed.Seconds())
+}
+
+// IncRetry 记录一次重试
+func IncRetry(operation string) {
+	retries.WithLabelValues(operation).Inc()
+}
+
+// IncWecomFailure 记录一次企业微信告警发送失败
+func IncWecomFailure() {
+	wecomFailures.Inc()
+}
+
+// JobStarted 后台任务开始执行，任务结束时调用返回的函数
+func JobStarted(jobType string) func() {
+	gauge := jobsInFlight.WithLabelValues(jobType)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// SetNewestBackup 更新环境最新已导出备份的时间，早于已记录的时间时忽略
+func SetNewestBackup(provider, env string, t time.Time) {
+	backupAges.set(provider, env, t)
+}
+
+// DeleteNewestBackup 环境从配置中移除后不再输出其备份时间
+func DeleteNewestBackup(provider, env string) {
+	backupAges.delete(provider, env)
+}
+
+// backupKey 云厂商和环境
+type backupKey struct {
+	provider string
+	env      string
+}
+
+// backupAgeCollector 在采集时根据最新备份时间计算备份年龄，两次导出之间年龄持续增长
+type backupAgeCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.Mutex
+	times map[backupKey]time.Time
+}
+
+func newBackupAgeCollector() *backupAgeCollector {
+	return &backupAgeCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "newest_backup_age_seconds"),
+			"Seconds since the newest successfully exported backup was taken, by provider and env.",
+			[]string{"provider", "env"}, nil),
+		times: make(map[backupKey]time.Time),
+	}
+}
+
+func (c *backupAgeCollector) set(provider, env string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := backupKey{provider, env}
+	if old, ok := c.times[key]; ok && old.After(t) {
+		return
+	}
+	c.times[key] = t
+}
+
+func (c *backupAgeCollector) delete(provider, env string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.times, backupKey{provider, env})
+}
+
+func (c *backupAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *backupAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, t := range c.times {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(t).Seconds(), key.provider, key.env)
+	}
+}
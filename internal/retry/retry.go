@@ -0,0 +1,258 @@
+// Package retry 为云端接口调用提供统一的重试：指数退避加随机抖动，只重试限流、5xx、超时和网络错误，
+// 认证失败、资源不存在等永久错误立即返回。各操作的重试策略在 config.yaml 的 retry 中配置
+package retry
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/metrics"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// 操作名称，对应配置中 retry.operations 的键
+const (
+	// OpDescribe 查询备份、快照、实例和导出任务
+	OpDescribe = "describe"
+	// OpExport 启动和取消快照导出任务
+	OpExport = "export"
+	// OpUpload 上传到存储目标、写入校验和
+	OpUpload = "upload"
+)
+
+// Policy 重试策略
+type Policy struct {
+	// MaxAttempts 总调用次数（含第一次），<=1 时不重试
+	MaxAttempts int
+	// InitialDelay 第一次重试前的等待时间，之后每次乘以 Multiplier
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Jitter 等待时间的随机抖动比例（0~1），避免多个调用同时重试
+	Jitter float64
+
+	// op 策略所属的操作，用于统计重试次数
+	op string
+}
+
+// defaultPolicy 配置中未设置的字段使用的默认值
+var defaultPolicy = Policy{
+	MaxAttempts:  3,
+	InitialDelay: time.Second,
+	MaxDelay:     20 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// For 操作的重试策略：retry.operations 中的设置优先，其次 retry.default，最后使用内置默认值
+func For(op string) Policy {
+	cfg := config.GetConfig().Retry
+	p := defaultPolicy.merge(cfg.Default)
+	if c, ok := cfg.Operations[op]; ok {
+		p = p.merge(c)
+	}
+	p.op = op
+	return p
+}
+
+// merge 用配置中非零的字段覆盖策略，jitter 设置为 0 时关闭抖动
+func (p Policy) merge(c config.RetryPolicy) Policy {
+	if c.MaxAttempts > 0 {
+		p.MaxAttempts = c.MaxAttempts
+	}
+	if c.InitialDelay > 0 {
+		p.InitialDelay = c.InitialDelay
+	}
+	if c.MaxDelay > 0 {
+		p.MaxDelay = c.MaxDelay
+	}
+	if c.Multiplier >= 1 {
+		p.Multiplier = c.Multiplier
+	}
+	if c.Jitter != nil && *c.Jitter >= 0 && *c.Jitter <= 1 {
+		p.Jitter = *c.Jitter
+	}
+	return p
+}
+
+// Delay 第 attempt 次调用失败后的等待时间
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
+
+// BackoffDelay 实现 AWS SDK 重试器的 BackoffDelayer，使 SDK 内部的重试使用相同的退避策略，
+// SDK 每次重试前调用，同时计入重试次数
+func (p Policy) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	metrics.IncRetry(p.op)
+	return p.Delay(attempt), nil
+}
+
+// attemptsKey TrackAttempts 在 context 中保存的调用次数
+type attemptsKey struct{}
+
+// TrackAttempts 返回记录调用次数的 ctx，attempts 返回使用该 ctx 的各次 Do 中调用次数最多的一次（含第一次）
+func TrackAttempts(ctx context.Context) (context.Context, func() int) {
+	var n atomic.Int32
+	return context.WithValue(ctx, attemptsKey{}, &n), func() int { return int(n.Load()) }
+}
+
+// recordAttempt 记录第 attempt 次调用
+func recordAttempt(ctx context.Context, attempt int) {
+	n, ok := ctx.Value(attemptsKey{}).(*atomic.Int32)
+	if !ok {
+		return
+	}
+	for {
+		old := n.Load()
+		if int32(attempt) <= old || n.CompareAndSwap(old, int32(attempt)) {
+			return
+		}
+	}
+}
+
+// Do 按操作的重试策略调用 fn，可重试的错误在等待后重试，永久错误、次数用完或 ctx 结束时返回最后一次的错误
+func Do(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	p := For(op)
+	for attempt := 1; ; attempt++ {
+		recordAttempt(ctx, attempt)
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if ctx.Err() != nil || attempt >= p.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		metrics.IncRetry(op)
+		logger.LogWarn("Cloud call failed, retrying",
+			logger.String("operation", op),
+			logger.Int("attempt", attempt),
+			logger.Int("max_attempts", p.MaxAttempts),
+			logger.Duration("delay", delay),
+			logger.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// DoValue 与 Do 相同，返回 fn 的结果
+func DoValue[T any](ctx context.Context, op string, fn func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	err := Do(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, err = fn(ctx)
+		return err
+	})
+	return value, err
+}
+
+// permanentError 标记不应重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装 fn 返回的错误，使 Do 不再重试并返回原始错误
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// transientCodes 各云厂商表示限流或服务端暂时不可用的错误码
+var transientCodes = map[string]bool{
+	"Throttling":                  true,
+	"ThrottlingException":         true,
+	"ThrottledException":          true,
+	"RequestThrottled":            true,
+	"RequestThrottledException":   true,
+	"TooManyRequestsException":    true,
+	"RequestLimitExceeded":        true,
+	"SlowDown":                    true,
+	"PriorRequestNotComplete":     true,
+	"RequestTimeout":              true,
+	"RequestTimeoutException":     true,
+	"InternalError":               true,
+	"InternalFailure":             true,
+	"ServiceUnavailable":          true,
+	"ServiceUnavailableException": true,
+	"UnknownError":                true,
+}
+
+// IsRetryable 判断错误能否重试。SDK 错误通过 ErrorCode() 和 HTTPStatusCode() 判断，
+// 限流、5xx、408/429、超时和连接中断（ECONNRESET、ECONNREFUSED、EPIPE、意外 EOF）可以重试，
+// 其余 4xx（认证失败、资源不存在、参数错误）、其他网络错误及未知错误不重试
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		code := coded.ErrorCode()
+		if transientCodes[code] || strings.HasPrefix(code, "Throttling.") || strings.HasPrefix(code, "ServiceUnavailable.") {
+			return true
+		}
+	}
+
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) && status.HTTPStatusCode() > 0 {
+		code := status.HTTPStatusCode()
+		return code >= http.StatusInternalServerError ||
+			code == http.StatusTooManyRequests ||
+			code == http.StatusRequestTimeout
+	}
+
+	// 只重试超时和连接中断，证书校验失败、域名不存在、地址错误等不会因重试而恢复
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
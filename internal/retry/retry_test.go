@@ -0,0 +1,171 @@
+package retry
+
+import (
+	"backuprds/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	p := Policy{InitialDelay: time.Second, MaxDelay: 20 * time.Second, Multiplier: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 20 * time.Second},
+		{100, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyDelayJitter(t *testing.T) {
+	p := Policy{InitialDelay: 10 * time.Second, MaxDelay: time.Minute, Multiplier: 2, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if got := p.Delay(1); got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("Delay(1) = %v, want within 8s..12s", got)
+		}
+	}
+}
+
+func TestPolicyMerge(t *testing.T) {
+	zero, half, invalid := 0.0, 0.5, 1.5
+
+	tests := []struct {
+		name string
+		cfg  config.RetryPolicy
+		want Policy
+	}{
+		{"empty keeps defaults", config.RetryPolicy{}, defaultPolicy},
+		{
+			"overrides set fields",
+			config.RetryPolicy{MaxAttempts: 5, InitialDelay: 2 * time.Second, Jitter: &half},
+			Policy{MaxAttempts: 5, InitialDelay: 2 * time.Second, MaxDelay: 20 * time.Second, Multiplier: 2, Jitter: 0.5},
+		},
+		{
+			"zero jitter disables jitter",
+			config.RetryPolicy{Jitter: &zero},
+			Policy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 20 * time.Second, Multiplier: 2},
+		},
+		{"out of range values are ignored", config.RetryPolicy{Multiplier: 0.5, Jitter: &invalid}, defaultPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultPolicy.merge(tt.cfg); got != tt.want {
+				t.Errorf("merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// codedError 模拟 SDK 返回的带错误码和 HTTP 状态码的错误
+type codedError struct {
+	code   string
+	status int
+}
+
+func (e *codedError) Error() string       { return e.code }
+func (e *codedError) ErrorCode() string   { return e.code }
+func (e *codedError) HTTPStatusCode() int { return e.status }
+
+// timeoutError 模拟超时的网络错误
+type timeoutError struct{ timeout bool }
+
+func (e *timeoutError) Error() string   { return "network error" }
+func (e *timeoutError) Timeout() bool   { return e.timeout }
+func (e *timeoutError) Temporary() bool { return false }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{"permanent", Permanent(&codedError{"Throttling", 400}), false},
+		{"throttling code", &codedError{"Throttling", 400}, true},
+		{"aliyun throttling code", &codedError{"Throttling.User", 400}, true},
+		{"wrapped throttling code", fmt.Errorf("describe: %w", &codedError{"SlowDown", 503}), true},
+		{"server error", &codedError{"Unexpected", 502}, true},
+		{"too many requests", &codedError{"Unexpected", 429}, true},
+		{"request timeout status", &codedError{"Unexpected", 408}, true},
+		{"access denied", &codedError{"AccessDenied", 403}, false},
+		{"not found", &codedError{"DBSnapshotNotFound", 404}, false},
+		{"network timeout", &timeoutError{timeout: true}, true},
+		{"network error without timeout", &timeoutError{timeout: false}, false},
+		{"dns not found", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, false},
+		{"connection reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"broken pipe", &net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}, true},
+		{"unexpected eof", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{"unknown error", errors.New("invalid parameter"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoStopsOnPermanentErrors(t *testing.T) {
+	notFound := &codedError{"DBSnapshotNotFound", 404}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{"success", nil, nil},
+		{"non retryable", notFound, notFound},
+		{"permanent unwraps", Permanent(notFound), notFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, attempts := TrackAttempts(context.Background())
+			err := Do(ctx, OpDescribe, func(context.Context) error { return tt.err })
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := attempts(); got != 1 {
+				t.Errorf("attempts = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestDoRetriesRetryableErrors(t *testing.T) {
+	ctx, attempts := TrackAttempts(context.Background())
+	calls := 0
+	value, err := DoValue(ctx, OpDescribe, func(context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "ok", nil
+	})
+	if err != nil || value != "ok" {
+		t.Fatalf("DoValue() = %q, %v, want ok", value, err)
+	}
+	if got := attempts(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
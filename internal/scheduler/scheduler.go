@@ -0,0 +1,346 @@
+// Package scheduler 按 cron 表达式定时触发阿里云和AWS的备份导出
+package scheduler
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/jobs"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/export"
+	"backuprds/internal/store"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 最近一次执行结果
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Status 定时任务的运行状态
+type Status struct {
+	Name          string     `json:"name"`
+	Cron          string     `json:"cron"`
+	Provider      string     `json:"provider"`
+	Envs          []string   `json:"envs"`
+	Running       bool       `json:"running"`
+	NextRun       *time.Time `json:"next_run,omitempty"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastFinished  *time.Time `json:"last_finished,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty"`
+	LastSucceeded []string   `json:"last_succeeded,omitempty"`
+	LastFailed    []string   `json:"last_failed,omitempty"`
+	SkippedRuns   int        `json:"skipped_runs"`
+}
+
+type schedule struct {
+	mu      sync.Mutex
+	entryID cron.EntryID
+	status  Status
+}
+
+// Scheduler 定时导出调度器
+type Scheduler struct {
+	cron      *cron.Cron
+	schedules []*schedule
+}
+
+var global atomic.Pointer[Scheduler]
+
+// runCtx 定时任务执行使用的 context，重新加载配置时不取消，Shutdown 时取消
+var runCtx, cancelRuns = context.WithCancel(context.Background())
+
+// Start 根据配置创建并启动全局调度器
+func Start(cfg *config.Config) error {
+	s, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	s.cron.Start()
+	global.Store(s)
+
+	logger.LogInfo("Scheduler started",
+		logger.Int("schedules", len(s.schedules)))
+	return nil
+}
+
+// Reload 按新配置重建全局调度器，同名定时任务保留运行状态，正在执行的任务不受影响
+func Reload(cfg *config.Config) error {
+	old := global.Load()
+	if old == nil {
+		return nil
+	}
+
+	prev := make(map[string]*schedule, len(old.schedules))
+	for _, sch := range old.schedules {
+		prev[sch.status.Name] = sch
+	}
+	s, err := build(cfg, prev)
+	if err != nil {
+		return err
+	}
+	old.cron.Stop()
+	s.cron.Start()
+	global.Store(s)
+
+	logger.LogInfo("Scheduler reloaded",
+		logger.Int("schedules", len(s.schedules)))
+	return nil
+}
+
+// Shutdown 停止全局调度器并取消正在执行的定时任务，等待任务返回或 ctx 取消
+func Shutdown(ctx context.Context) error {
+	cancelRuns()
+	s := global.Load()
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case <-s.Stop().Done():
+		logger.LogInfo("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetScheduler 返回全局调度器，未启动时返回 nil
+func GetScheduler() *Scheduler {
+	return global.Load()
+}
+
+// New 校验定时任务配置并注册到 cron，不启动调度
+func New(cfg *config.Config) (*Scheduler, error) {
+	return build(cfg, nil)
+}
+
+// build 创建调度器，prev 中同名的定时任务被复用以保留运行状态；
+// 全部定时任务校验通过后才修改复用的任务，失败时 prev 保持不变
+func build(cfg *config.Config, prev map[string]*schedule) (*Scheduler, error) {
+	s := &Scheduler{cron: cron.New()}
+
+	type entry struct {
+		sch  *schedule
+		id   cron.EntryID
+		sc   config.ScheduleConfig
+		envs []string
+	}
+	var entries []entry
+
+	seen := make(map[string]bool)
+	for _, sc := range cfg.Schedules.Tasks {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("schedule name is required")
+		}
+		if seen[sc.Name] {
+			return nil, fmt.Errorf("duplicate schedule name: %s", sc.Name)
+		}
+		seen[sc.Name] = true
+
+		envs, err := resolveEnvs(cfg, sc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %v", sc.Name, err)
+		}
+
+		sch, ok := prev[sc.Name]
+		if !ok {
+			sch = &schedule{}
+		}
+		id, err := s.cron.AddFunc(sc.Cron, func() { s.run(sch) })
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: invalid cron expression %q: %v", sc.Name, sc.Cron, err)
+		}
+		entries = append(entries, entry{sch: sch, id: id, sc: sc, envs: envs})
+	}
+
+	for _, e := range entries {
+		e.sch.mu.Lock()
+		e.sch.entryID = e.id
+		e.sch.status.Name = e.sc.Name
+		e.sch.status.Cron = e.sc.Cron
+		e.sch.status.Provider = e.sc.Provider
+		e.sch.status.Envs = e.envs
+		e.sch.mu.Unlock()
+		s.schedules = append(s.schedules, e.sch)
+	}
+	return s, nil
+}
+
+// Stop 停止调度，返回的 ctx 在正在执行的任务结束后关闭
+func (s *Scheduler) Stop() context.Context {
+	return s.cron.Stop()
+}
+
+// List 返回所有定时任务的状态
+func (s *Scheduler) List() []Status {
+	list := make([]Status, 0, len(s.schedules))
+	for _, sch := range s.schedules {
+		sch.mu.Lock()
+		st := sch.status
+		entryID := sch.entryID
+		sch.mu.Unlock()
+
+		if next := s.cron.Entry(entryID).Next; !next.IsZero() {
+			st.NextRun = &next
+		}
+		list = append(list, st)
+	}
+	return list
+}
+
+// resolveEnvs 展开定时任务对应的环境列表并校验环境是否存在
+func resolveEnvs(cfg *config.Config, sc config.ScheduleConfig) ([]string, error) {
+	var instances map[string]config.InstanceConfig
+	switch sc.Provider {
+	case store.ProviderAliyun:
+		instances = cfg.RDS.Aliyun.Instances
+	case store.ProviderAws:
+		instances = cfg.RDS.Aws.Instances
+	default:
+		return nil, fmt.Errorf("invalid provider %q, expected aliyun or aws", sc.Provider)
+	}
+
+	envs := append([]string{}, sc.Envs...)
+	if sc.Group != "" {
+		group, ok := cfg.Schedules.Groups[sc.Group]
+		if !ok {
+			return nil, fmt.Errorf("unknown env group %q", sc.Group)
+		}
+		envs = append(envs, group...)
+	}
+
+	// 未指定环境时导出全部环境
+	if len(envs) == 0 {
+		for env := range instances {
+			envs = append(envs, env)
+		}
+	}
+
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(envs))
+	for _, env := range envs {
+		if seen[env] {
+			continue
+		}
+		seen[env] = true
+		if _, ok := instances[env]; !ok {
+			return nil, fmt.Errorf("unknown %s env %q", sc.Provider, env)
+		}
+		result = append(result, env)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// run 执行一次定时任务，上一次执行未结束时跳过
+func (s *Scheduler) run(sch *schedule) {
+	sch.mu.Lock()
+	if sch.status.Running {
+		sch.status.SkippedRuns++
+		sch.mu.Unlock()
+		logger.LogWarn("Skipping scheduled export, previous run still in progress",
+			logger.String("schedule", sch.status.Name))
+		return
+	}
+	now := time.Now()
+	sch.status.Running = true
+	sch.status.LastRun = &now
+	name, provider, envs := sch.status.Name, sch.status.Provider, sch.status.Envs
+	sch.mu.Unlock()
+
+	logger.LogInfo("Scheduled export started",
+		logger.String("schedule", name),
+		logger.String("provider", provider),
+		logger.String("envs", strings.Join(envs, ",")))
+
+	var succeeded, failed []string
+	if provider == store.ProviderAliyun {
+		succeeded, failed = runAliyun(runCtx, envs)
+	} else {
+		succeeded, failed = runAws(runCtx, envs)
+	}
+
+	finished := time.Now()
+	sch.mu.Lock()
+	sch.status.Running = false
+	sch.status.LastFinished = &finished
+	sch.status.LastSucceeded = succeeded
+	sch.status.LastFailed = failed
+	if len(failed) > 0 {
+		sch.status.LastStatus = StatusFailed
+	} else {
+		sch.status.LastStatus = StatusSucceeded
+	}
+	sch.mu.Unlock()
+
+	if len(failed) > 0 {
+		logger.LogError("Scheduled export finished with failures",
+			logger.String("schedule", name),
+			logger.Int("succeeded", len(succeeded)),
+			logger.Int("failed", len(failed)),
+			logger.String("failed_envs", strings.Join(failed, ",")),
+			logger.Duration("duration", finished.Sub(now)))
+		return
+	}
+	logger.LogInfo("Scheduled export finished",
+		logger.String("schedule", name),
+		logger.Int("succeeded", len(succeeded)),
+		logger.Duration("duration", finished.Sub(now)))
+}
+
+// runAliyun 为每个环境提交上传任务并等待全部结束，ctx 取消时不再等待，未结束的环境记为失败
+func runAliyun(ctx context.Context, envs []string) (succeeded, failed []string) {
+	submitted := make(map[string]string)
+	for _, env := range envs {
+		job, err := export.SubmitAliyunToS3(env, export.AliyunOptions{})
+		if err != nil {
+			logger.LogError("Failed to submit scheduled export",
+				logger.String("env", env),
+				logger.Error(err))
+			failed = append(failed, env)
+			continue
+		}
+		submitted[env] = job.ID
+	}
+
+	for _, env := range envs {
+		id, ok := submitted[env]
+		if !ok {
+			continue
+		}
+		job, err := jobs.GetManager().Wait(ctx, id)
+		if err != nil || job.State != jobs.StateSucceeded {
+			failed = append(failed, env)
+			continue
+		}
+		succeeded = append(succeeded, env)
+	}
+	return succeeded, failed
+}
+
+// runAws 依次为每个环境启动快照导出任务
+func runAws(ctx context.Context, envs []string) (succeeded, failed []string) {
+	for _, env := range envs {
+		result, err := export.AwsSnapshot(ctx, env, "")
+		if err != nil {
+			logger.LogError("Failed to start scheduled AWS export",
+				logger.String("env", env),
+				logger.Error(err))
+			failed = append(failed, env)
+			continue
+		}
+		logger.LogInfo("Scheduled AWS export started",
+			logger.String("env", env),
+			logger.String("export_task_id", result.ExportTaskID))
+		succeeded = append(succeeded, env)
+	}
+	return succeeded, failed
+}
@@ -0,0 +1,172 @@
+package aliyun
+
+import (
+	"backuprds/internal/config"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/aliyun/credentials-go/credentials"
+)
+
+// 凭证类型
+const (
+	// CredentialDefault 默认凭证链：环境变量、RRSA OIDC、CLI 配置文件、ECS 实例 RAM 角色
+	CredentialDefault   = "default"
+	CredentialAccessKey = "access_key"
+	CredentialECSRole   = "ecs_ram_role"
+	CredentialRoleArn   = "ram_role_arn"
+	CredentialOIDC      = "oidc_role_arn"
+)
+
+// 默认的访问密钥环境变量
+const (
+	envAccessKeyID     = "ALIBABA_CLOUD_ACCESS_KEY_ID"
+	envAccessKeySecret = "ALIBABA_CLOUD_ACCESS_KEY_SECRET"
+)
+
+// DefaultSessionName 未配置 roleSessionName 时 AssumeRole 使用的会话名称
+const DefaultSessionName = "backuprds"
+
+// ErrUnknownProfile 配置中没有该凭证
+var ErrUnknownProfile = errors.New("unknown aliyun credential profile")
+
+// Target 调用阿里云接口的 region 和凭证
+type Target struct {
+	Region string
+	// Profile rds.aliyun.credentials 中的凭证名称，为空时使用默认凭证链
+	Profile string
+}
+
+// TargetFor 实例所在 region 和访问实例使用的凭证
+func TargetFor(instance config.InstanceConfig) Target {
+	return Target{Region: instance.Region, Profile: instance.Profile}
+}
+
+// newCredential 根据凭证名称创建凭证，凭证由 SDK 缓存并在过期前自动刷新
+func newCredential(profile string) (credentials.Credential, error) {
+	if profile == "" {
+		return credentials.NewCredential(nil)
+	}
+
+	c, ok := config.GetConfig().RDS.Aliyun.Credentials[profile]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProfile, profile)
+	}
+	credConfig, err := credentialConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("credential profile %s: %v", profile, err)
+	}
+	return credentials.NewCredential(credConfig)
+}
+
+// credentialConfig 将配置文件中的凭证转换为 SDK 的凭证配置，default 类型返回 nil
+func credentialConfig(c config.AliyunCredentialConfig) (*credentials.Config, error) {
+	switch c.Type {
+	case CredentialDefault, "":
+		return nil, nil
+	case CredentialAccessKey:
+		accessKey, secret, err := accessKeyFromEnv(c)
+		if err != nil {
+			return nil, err
+		}
+		return new(credentials.Config).
+			SetType(CredentialAccessKey).
+			SetAccessKeyId(accessKey).
+			SetAccessKeySecret(secret), nil
+	case CredentialECSRole:
+		credConfig := new(credentials.Config).SetType(CredentialECSRole)
+		if c.RoleName != "" {
+			credConfig.SetRoleName(c.RoleName)
+		}
+		return credConfig, nil
+	case CredentialRoleArn:
+		if err := validateCredential(c); err != nil {
+			return nil, err
+		}
+		accessKey, secret, err := accessKeyFromEnv(c)
+		if err != nil {
+			return nil, err
+		}
+		credConfig := new(credentials.Config).
+			SetType(CredentialRoleArn).
+			SetAccessKeyId(accessKey).
+			SetAccessKeySecret(secret).
+			SetRoleArn(c.RoleArn).
+			SetRoleSessionName(sessionName(c))
+		if c.ExternalID != "" {
+			credConfig.ExternalId = tea.String(c.ExternalID)
+		}
+		return credConfig, nil
+	case CredentialOIDC:
+		credConfig := new(credentials.Config).
+			SetType(CredentialOIDC).
+			SetRoleArn(firstNonEmpty(c.RoleArn, os.Getenv("ALIBABA_CLOUD_ROLE_ARN"))).
+			SetOIDCProviderArn(firstNonEmpty(c.OIDCProviderArn, os.Getenv("ALIBABA_CLOUD_OIDC_PROVIDER_ARN"))).
+			SetOIDCTokenFilePath(firstNonEmpty(c.OIDCTokenFile, os.Getenv("ALIBABA_CLOUD_OIDC_TOKEN_FILE"))).
+			SetRoleSessionName(sessionName(c))
+		if tea.StringValue(credConfig.RoleArn) == "" || tea.StringValue(credConfig.OIDCProviderArn) == "" || tea.StringValue(credConfig.OIDCTokenFilePath) == "" {
+			return nil, errors.New("roleArn, oidcProviderArn and oidcTokenFile are required")
+		}
+		return credConfig, nil
+	}
+	return nil, fmt.Errorf("invalid type %q", c.Type)
+}
+
+// ValidateCredentials 检查凭证配置的类型和必填项以及实例引用的凭证，不读取环境变量
+func ValidateCredentials(cfg *config.Config) error {
+	for name, c := range cfg.RDS.Aliyun.Credentials {
+		if err := validateCredential(c); err != nil {
+			return fmt.Errorf("credential profile %s: %v", name, err)
+		}
+	}
+	for env, instance := range cfg.RDS.Aliyun.Instances {
+		if instance.Profile == "" {
+			continue
+		}
+		if _, ok := cfg.RDS.Aliyun.Credentials[instance.Profile]; !ok {
+			return fmt.Errorf("instance %s: %w: %s", env, ErrUnknownProfile, instance.Profile)
+		}
+	}
+	return nil
+}
+
+func validateCredential(c config.AliyunCredentialConfig) error {
+	switch c.Type {
+	case CredentialDefault, "", CredentialAccessKey, CredentialECSRole, CredentialOIDC:
+		return nil
+	case CredentialRoleArn:
+		if c.RoleArn == "" {
+			return errors.New("roleArn is required")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid type %q", c.Type)
+}
+
+// accessKeyFromEnv 从配置指定的环境变量读取访问密钥，未指定时使用默认的环境变量
+func accessKeyFromEnv(c config.AliyunCredentialConfig) (string, string, error) {
+	keyEnv := firstNonEmpty(c.AccessKeyEnv, envAccessKeyID)
+	secretEnv := firstNonEmpty(c.SecretKeyEnv, envAccessKeySecret)
+
+	accessKey := os.Getenv(keyEnv)
+	secret := os.Getenv(secretEnv)
+	if accessKey == "" || secret == "" {
+		return "", "", fmt.Errorf("missing required environment variables: %s or %s", keyEnv, secretEnv)
+	}
+	return accessKey, secret, nil
+}
+
+func sessionName(c config.AliyunCredentialConfig) string {
+	return firstNonEmpty(c.SessionName, DefaultSessionName)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -2,8 +2,15 @@
 package aliyun
 
 import (
+	"backuprds/internal/metrics"
+	"backuprds/internal/retry"
+	"backuprds/internal/service/clientpool"
+	"backuprds/internal/store"
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
+	"time"
 
 	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
 	rds20140815 "github.com/alibabacloud-go/rds-20140815/v8/client"
@@ -11,62 +18,320 @@ import (
 	"github.com/alibabacloud-go/tea/tea"
 )
 
-// CreateClient 创建 RDS 客户端
-func CreateClient() (*rds20140815.Client, error) {
-	accessKey := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_ID")
-	accessSecret := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+const (
+	// DescribeBackups 单页最大条数
+	backupPageSize = 100
+	// DescribeBackups 要求的时间格式 (UTC)
+	backupTimeLayout = "2006-01-02T15:04Z"
+	// 只指定结束时间时向前查询的时间范围
+	defaultBackupWindow = 30 * 24 * time.Hour
+	// 备份成功的状态
+	backupStatusSuccess = "Success"
+)
+
+var (
+	// ErrBackupNotFound 指定的备份集不存在
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrInstanceNotFound 实例不存在
+	ErrInstanceNotFound = errors.New("instance not found")
+)
+
+// Instance 阿里云RDS实例信息
+type Instance struct {
+	InstanceID    string `json:"instance_id"`
+	Description   string `json:"description"`
+	Engine        string `json:"engine"`
+	EngineVersion string `json:"engine_version"`
+	RegionID      string `json:"region_id"`
+	Status        string `json:"status"`
+}
 
-	if accessKey == "" || accessSecret == "" {
-		return nil, fmt.Errorf("missing required environment variables: ALIBABA_CLOUD_ACCESS_KEY_ID or ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+// Backup 阿里云RDS备份集
+type Backup struct {
+	BackupID            string `json:"backup_id"`
+	Method              string `json:"method"`
+	Mode                string `json:"mode"`
+	Type                string `json:"type"`
+	Status              string `json:"status"`
+	Size                int64  `json:"size"`
+	StartTime           string `json:"start_time"`
+	EndTime             string `json:"end_time"`
+	ConsistentTime      int64  `json:"consistent_time,omitempty"`
+	DownloadURL         string `json:"download_url"`
+	IntranetDownloadURL string `json:"intranet_download_url"`
+}
+
+// BackupFilter 备份查询条件，零值字段不参与过滤
+type BackupFilter struct {
+	BackupID string
+	Start    time.Time
+	End      time.Time
+	// Status 备份状态 Success/Failed
+	Status string
+	// Method 备份方式 physical/logical/snapshot，不区分大小写
+	Method string
+}
+
+// CreateClient 使用目标的凭证创建 RDS 客户端，接口地址按目标 region 选择
+func CreateClient(target Target) (*rds20140815.Client, error) {
+	credential, err := newCredential(target.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aliyun credentials: %v", err)
 	}
 
 	config := &openapi.Config{
-		AccessKeyId:     tea.String(accessKey),
-		AccessKeySecret: tea.String(accessSecret),
+		Credential: credential,
+	}
+	if target.Region != "" {
+		config.RegionId = tea.String(target.Region)
+	}
+	if endpoint := endpointFor(target.Region); endpoint != "" {
+		config.Endpoint = tea.String(endpoint)
 	}
-	config.Endpoint = tea.String("rds.aliyuncs.com")
 	return rds20140815.NewClient(config)
 }
 
-// GetLastBackupURLs 获取最新备份文件的下载链接，包括内网和公网
-func GetLastBackupURLs(instanceID string) (map[string]string, error) {
-	client, err := CreateClient()
+// rdsClients 按 region 和凭证缓存的 RDS 客户端
+var rdsClients = clientpool.New(CreateClient)
+
+// ResetClients 清空缓存的 RDS 客户端，配置重新加载后使用新的凭证和接口地址
+func ResetClients() {
+	rdsClients.Reset()
+}
+
+// call 在 ctx 取消时立即返回，SDK 不支持 context，调用本身在超时后结束，其结果被丢弃；
+// operation 为指标中的接口名称
+func call[T any](ctx context.Context, operation string, fn func() (T, error)) (_ T, err error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	start := time.Now()
+	defer func() {
+		metrics.ObserveCloudCall(store.ProviderAliyun, "RDS."+operation, start, err)
+	}()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// GetLatestBackup 获取默认时间范围内开始时间最新的成功备份集，失败和进行中的备份不参与比较，没有备份时返回 nil
+func GetLatestBackup(ctx context.Context, instanceID string, target Target) (*Backup, error) {
+	backups, err := ListBackups(ctx, instanceID, target, BackupFilter{
+		End:    time.Now(),
+		Status: backupStatusSuccess,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Backup
+	var latestStart time.Time
+	for i := range backups {
+		start, err := time.Parse(time.RFC3339, backups[i].StartTime)
+		if err != nil {
+			continue
+		}
+		if latest == nil || start.After(latestStart) {
+			latest, latestStart = &backups[i], start
+		}
+	}
+	return latest, nil
+}
+
+// GetBackup 根据备份集ID获取备份
+func GetBackup(ctx context.Context, instanceID string, target Target, backupID string) (*Backup, error) {
+	backups, err := ListBackups(ctx, instanceID, target, BackupFilter{BackupID: backupID})
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, ErrBackupNotFound
+	}
+	return &backups[0], nil
+}
+
+// ListBackups 分页获取时间范围内的全部备份集，按开始时间倒序返回
+func ListBackups(ctx context.Context, instanceID string, target Target, filter BackupFilter) ([]Backup, error) {
+	client, err := rdsClients.Get(target)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create RDS client: %v", err)
 	}
 
-	// 配置 DescribeBackups 请求
-	describeBackupsRequest := &rds20140815.DescribeBackupsRequest{
+	request := &rds20140815.DescribeBackupsRequest{
 		DBInstanceId: tea.String(instanceID),
+		PageSize:     tea.Int32(backupPageSize),
+	}
+	if filter.BackupID != "" {
+		request.BackupId = tea.String(filter.BackupID)
+	}
+	if filter.Status != "" {
+		request.BackupStatus = tea.String(filter.Status)
+	}
+
+	// 开始和结束时间需同时指定
+	start, end := filter.Start, filter.End
+	if !start.IsZero() && end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() && !end.IsZero() {
+		start = end.Add(-defaultBackupWindow)
 	}
+	if !start.IsZero() {
+		request.StartTime = tea.String(start.UTC().Format(backupTimeLayout))
+		request.EndTime = tea.String(end.UTC().Format(backupTimeLayout))
+	}
+
+	var backups []Backup
+	for page := int32(1); ; page++ {
+		request.PageNumber = tea.Int32(page)
+
+		resp, err := describeBackups(ctx, client, request)
+		if err != nil {
+			return nil, err
+		}
+
+		items := resp.Body.Items.Backup
+		for _, item := range items {
+			backup := toBackup(item)
+			if filter.Method != "" && !strings.EqualFold(backup.Method, filter.Method) {
+				continue
+			}
+			backups = append(backups, backup)
+		}
+
+		if len(items) < backupPageSize {
+			break
+		}
+	}
+
+	return backups, nil
+}
+
+// describeBackups 调用 DescribeBackups 并统一处理 SDK 错误
+func describeBackups(ctx context.Context, client *rds20140815.Client, request *rds20140815.DescribeBackupsRequest) (*rds20140815.DescribeBackupsResponse, error) {
 	runtime := &util.RuntimeOptions{}
 
 	// 调用 DescribeBackupsWithOptions 获取备份信息
-	resp, err := client.DescribeBackupsWithOptions(describeBackupsRequest, runtime)
+	resp, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds20140815.DescribeBackupsResponse, error) {
+		resp, err := call(ctx, "DescribeBackups", func() (*rds20140815.DescribeBackupsResponse, error) {
+			return client.DescribeBackupsWithOptions(request, runtime)
+		})
+		return resp, apiError(err)
+	})
 	if err != nil {
-		// 错误处理，输出详细的错误信息
-		var sdkErr = &tea.SDKError{}
-		if _t, ok := err.(*tea.SDKError); ok {
-			sdkErr = _t
-		} else {
-			sdkErr.Message = tea.String(err.Error())
-		}
-		return nil, fmt.Errorf("API request error: %s", tea.StringValue(sdkErr.Message))
+		return nil, err
+	}
+	return resp, nil
+}
+
+// APIError 阿里云接口调用失败，保留错误码和 HTTP 状态码用于判断能否重试
+type APIError struct {
+	Code       string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return "API request error: " + e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode 阿里云错误码，如 Throttling.User、InvalidDBInstanceId.NotFound
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// HTTPStatusCode 接口返回的 HTTP 状态码，网络错误时为 0
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// apiError 统一处理 SDK 错误，输出详细的错误信息，请求被取消时原样返回
+func apiError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	apiErr := &APIError{Message: err.Error(), Err: err}
+	if sdkErr, ok := err.(*tea.SDKError); ok {
+		apiErr.Code = tea.StringValue(sdkErr.Code)
+		apiErr.StatusCode = tea.IntValue(sdkErr.StatusCode)
+		apiErr.Message = tea.StringValue(sdkErr.Message)
+	}
+	return apiErr
+}
+
+// GetInstance 通过 DescribeDBInstanceAttribute 获取实例信息
+func GetInstance(ctx context.Context, instanceID string, target Target) (*Instance, error) {
+	client, err := rdsClients.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RDS client: %v", err)
 	}
 
-	// 检查是否有备份
-	if len(resp.Body.Items.Backup) == 0 {
-		return map[string]string{
-			"BackupStartTime":           "",
-			"BackupDownloadURL":         "",
-			"BackupIntranetDownloadURL": "",
-		}, nil
+	resp, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds20140815.DescribeDBInstanceAttributeResponse, error) {
+		resp, err := call(ctx, "DescribeDBInstanceAttribute", func() (*rds20140815.DescribeDBInstanceAttributeResponse, error) {
+			return client.DescribeDBInstanceAttributeWithOptions(&rds20140815.DescribeDBInstanceAttributeRequest{
+				DBInstanceId: tea.String(instanceID),
+			}, &util.RuntimeOptions{})
+		})
+		return resp, apiError(err)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 获取最新备份的公网和内网下载 URL
-	return map[string]string{
-		"BackupStartTime":           tea.StringValue(resp.Body.Items.Backup[0].BackupStartTime),
-		"BackupDownloadURL":         tea.StringValue(resp.Body.Items.Backup[0].BackupDownloadURL),
-		"BackupIntranetDownloadURL": tea.StringValue(resp.Body.Items.Backup[0].BackupIntranetDownloadURL),
+	if resp.Body.Items == nil || len(resp.Body.Items.DBInstanceAttribute) == 0 {
+		return nil, ErrInstanceNotFound
+	}
+	attr := resp.Body.Items.DBInstanceAttribute[0]
+	return &Instance{
+		InstanceID:    tea.StringValue(attr.DBInstanceId),
+		Description:   tea.StringValue(attr.DBInstanceDescription),
+		Engine:        tea.StringValue(attr.Engine),
+		EngineVersion: tea.StringValue(attr.EngineVersion),
+		RegionID:      tea.StringValue(attr.RegionId),
+		Status:        tea.StringValue(attr.DBInstanceStatus),
 	}, nil
 }
+
+// RestoreTime 备份数据对应的时间点，优先使用一致性时间点，否则使用备份结束时间
+func (b *Backup) RestoreTime() (time.Time, error) {
+	if b.ConsistentTime > 0 {
+		return time.Unix(b.ConsistentTime, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, b.EndTime)
+}
+
+func toBackup(item *rds20140815.DescribeBackupsResponseBodyItemsBackup) Backup {
+	return Backup{
+		BackupID:            tea.StringValue(item.BackupId),
+		Method:              tea.StringValue(item.BackupMethod),
+		Mode:                tea.StringValue(item.BackupMode),
+		Type:                tea.StringValue(item.BackupType),
+		Status:              tea.StringValue(item.BackupStatus),
+		Size:                tea.Int64Value(item.BackupSize),
+		StartTime:           tea.StringValue(item.BackupStartTime),
+		EndTime:             tea.StringValue(item.BackupEndTime),
+		ConsistentTime:      tea.Int64Value(item.ConsistentTime),
+		DownloadURL:         tea.StringValue(item.BackupDownloadURL),
+		IntranetDownloadURL: tea.StringValue(item.BackupIntranetDownloadURL),
+	}
+}
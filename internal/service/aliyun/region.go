@@ -0,0 +1,90 @@
+package aliyun
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEndpoint 未配置 region 时使用的 RDS 接口地址
+	defaultEndpoint = "rds.aliyuncs.com"
+	// regionMetadataURL ECS 元数据服务中的 region
+	regionMetadataURL = "http://100.100.100.200/latest/meta-data/region-id"
+	// metadataTimeout 不在阿里云内运行时元数据服务不可达，超时后视为不在阿里云内
+	metadataTimeout = time.Second
+	// LocalRegionNone 配置为该值时不检测服务所在 region，始终使用公网下载链接
+	LocalRegionNone = "none"
+)
+
+var (
+	detectOnce     sync.Once
+	detectedRegion string
+)
+
+// endpointFor 返回 region 的 RDS 接口地址 rds.<region>.aliyuncs.com，开启 vpcEndpoint 时为 rds-vpc.<region>.aliyuncs.com；
+// 配置中的 endpoints 只用于例外的 region
+func endpointFor(region string) string {
+	cfg := config.GetConfig().RDS.Aliyun
+	if region == "" {
+		return defaultEndpoint
+	}
+	if endpoint, ok := cfg.Endpoints[region]; ok && endpoint != "" {
+		return endpoint
+	}
+	if cfg.VpcEndpoint {
+		return fmt.Sprintf("rds-vpc.%s.aliyuncs.com", region)
+	}
+	return fmt.Sprintf("rds.%s.aliyuncs.com", region)
+}
+
+// LocalRegion 服务所在的阿里云 region，配置的 localRegion 优先，未配置时从 ECS 元数据获取，
+// 不在阿里云内运行时返回空字符串
+func LocalRegion() string {
+	region := config.GetConfig().RDS.Aliyun.LocalRegion
+	if region == LocalRegionNone {
+		return ""
+	}
+	if region != "" {
+		return region
+	}
+
+	detectOnce.Do(func() {
+		detectedRegion = detectRegion()
+		if detectedRegion != "" {
+			logger.LogInfo("Detected aliyun region from ECS metadata",
+				logger.String("region", detectedRegion))
+		}
+	})
+	return detectedRegion
+}
+
+// SameRegion 服务是否与 region 位于同一阿里云 region，可以使用内网地址
+func SameRegion(region string) bool {
+	return region != "" && LocalRegion() == region
+}
+
+func detectRegion() string {
+	client := &http.Client{Timeout: metadataTimeout}
+	resp, err := client.Get(regionMetadataURL)
+	if err != nil {
+		logger.LogDebug("ECS metadata is not available",
+			logger.Error(err))
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
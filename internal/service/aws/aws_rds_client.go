@@ -3,55 +3,57 @@ package aws
 
 import (
 	"backuprds/internal/logger"
+	"backuprds/internal/retry"
+	"backuprds/internal/service/clientpool"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 )
 
-// createAWSClient 创建 RDS 客户端
-func createAWSClient(region string) (*rds.Client, error) {
-	// 从环境变量获取 AWS 凭证
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	if accessKey == "" || secretKey == "" {
-		return nil, fmt.Errorf("missing required environment variables: AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY")
-	}
-
-	// 使用传入的 region 创建配置
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			accessKey,
-			secretKey,
-			"", // token可以为空
-		)),
-	)
+// rdsClients 按 region 和凭证缓存的 RDS 客户端，客户端并发安全，临时凭证由 SDK 自动续期
+var rdsClients = clientpool.New(func(target Target) (*rds.Client, error) {
+	// 客户端在请求之间共享，加载配置不使用单个请求的 context
+	cfg, err := LoadConfig(context.Background(), target.Region, target.Credentials)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+		return nil, err
 	}
+	// 重试由 retry 按操作的策略处理，关闭 SDK 自带的重试，避免重试次数叠加
+	return rds.NewFromConfig(cfg, func(o *rds.Options) {
+		o.Retryer = aws.NopRetryer{}
+	}), nil
+})
 
-	return rds.NewFromConfig(cfg), nil
+// ResetClients 清空缓存的 RDS 客户端，配置重新加载后使用新的凭证配置
+func ResetClients() {
+	rdsClients.Reset()
+}
+
+// createAWSClient 获取目标 region 和凭证的 RDS 客户端
+func createAWSClient(target Target) (*rds.Client, error) {
+	return rdsClients.Get(target)
 }
 
 // StartRDSSnapshotExport 启动 RDS 快照导出任务
 func StartRDSSnapshotExport(
+	ctx context.Context,
 	instanceID string,
 	snapshotArn string,
-	region string,
+	target Target,
 	iamRoleArn string,
 	kmsKeyId string,
 	s3BucketName string,
 	s3Prefix string,
 ) (string, error) {
-	client, err := createAWSClient(region)
+	client, err := createAWSClient(target)
 	if err != nil {
 		return "", fmt.Errorf("startRDSSnapshotExport funcation failed to create AWS RDS client: %v", err)
 	}
@@ -65,11 +67,18 @@ func StartRDSSnapshotExport(
 			shortInstanceID = shortInstanceID[len(shortInstanceID)-20:]
 		}
 	}
+	// 标识符不能包含连续的连字符
+	shortInstanceID = strings.TrimLeft(shortInstanceID, "-")
 
-	// 生成更短的导出任务标识符
-	exportTaskIdentifier := fmt.Sprintf("exp-%s-%s",
+	// 导出任务标识符在账号和 region 内唯一，时间精确到秒并加随机后缀，同一快照可以多次导出
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate export task identifier: %v", err)
+	}
+	exportTaskIdentifier := fmt.Sprintf("exp-%s-%s-%s",
 		shortInstanceID,
-		time.Now().Format("0102-1504"))
+		time.Now().Format("20060102-150405"),
+		hex.EncodeToString(suffix))
 
 	// 构建完整的 S3 前缀路径
 	fullS3Prefix := s3Prefix
@@ -89,75 +98,275 @@ func StartRDSSnapshotExport(
 	logger.LogInfo("Starting export task",
 		logger.Any("params", input))
 
-	result, err := client.StartExportTask(context.TODO(), input)
+	taskID, err := retry.DoValue(ctx, retry.OpExport, func(ctx context.Context) (string, error) {
+		result, err := client.StartExportTask(ctx, input)
+		if err == nil {
+			return aws.ToString(result.ExportTaskIdentifier), nil
+		}
+		// 上一次调用超时或连接中断时任务可能已经创建，任务已存在且来源相同视为启动成功
+		var exists *types.ExportTaskAlreadyExistsFault
+		if !errors.As(err, &exists) {
+			return "", err
+		}
+		task, getErr := GetExportTask(ctx, target, exportTaskIdentifier)
+		if getErr != nil {
+			return "", fmt.Errorf("%w (failed to describe existing export task: %v)", err, getErr)
+		}
+		if task.SourceArn != snapshotArn {
+			return "", fmt.Errorf("%w (existing export task source: %s)", err, task.SourceArn)
+		}
+		logger.LogInfo("Export task was created by a previous attempt",
+			logger.String("export_task_id", task.ExportTaskID))
+		return task.ExportTaskID, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to start export task: %v", err)
+		return "", fmt.Errorf("failed to start export task: %w", err)
 	}
 
-	return aws.ToString(result.ExportTaskIdentifier), nil
+	return taskID, nil
 }
 
-// GetLatestSnapshotInfo 获取最新的 AWS RDS 快照信息
-func GetLatestSnapshotInfo(instanceID string, region string) (map[string]string, error) {
-	logger.LogInfo("Fetching latest snapshot info",
-		logger.String("instance_id", instanceID),
-		logger.String("region", region))
+// 快照类型
+const (
+	SnapshotTypeAutomated = "automated"
+	SnapshotTypeManual    = "manual"
+	SnapshotTypeShared    = "shared"
+)
+
+// SnapshotStatusAvailable 可用于导出的快照状态
+const SnapshotStatusAvailable = "available"
+
+var (
+	// ErrSnapshotNotFound 快照不存在或不属于该实例
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	// ErrInvalidSnapshotType 不支持的快照类型
+	ErrInvalidSnapshotType = errors.New("invalid snapshot type, expected automated, manual or shared")
+)
+
+// Snapshot AWS RDS快照
+type Snapshot struct {
+	SnapshotID       string     `json:"snapshot_id"`
+	SnapshotArn      string     `json:"snapshot_arn"`
+	Type             string     `json:"type"`
+	Status           string     `json:"status"`
+	CreateTime       *time.Time `json:"create_time,omitempty"`
+	InstanceID       string     `json:"instance_id"`
+	Engine           string     `json:"engine"`
+	EngineVersion    string     `json:"engine_version"`
+	AllocatedStorage int32      `json:"allocated_storage_gb"`
+	Encrypted        bool       `json:"encrypted"`
+	KmsKeyId         string     `json:"kms_key_id,omitempty"`
+}
+
+// SnapshotFilter 快照查询条件，零值字段不参与过滤
+type SnapshotFilter struct {
+	// Type 快照类型 automated/manual/shared，为空时查询全部类型
+	Type   string
+	Status string
+	Start  time.Time
+	End    time.Time
+}
+
+// ListSnapshots 分页查询实例的快照，按创建时间倒序返回
+func ListSnapshots(ctx context.Context, instanceID string, target Target, filter SnapshotFilter) ([]Snapshot, error) {
+	switch filter.Type {
+	case "", SnapshotTypeAutomated, SnapshotTypeManual, SnapshotTypeShared:
+	default:
+		return nil, ErrInvalidSnapshotType
+	}
 
-	client, err := createAWSClient(region)
+	client, err := createAWSClient(target)
 	if err != nil {
 		logger.LogError("Failed to create AWS client",
 			logger.Error(err),
-			logger.String("region", region))
+			logger.String("region", target.Region))
 		return nil, fmt.Errorf("failed to create AWS RDS client: %v", err)
 	}
 
 	input := &rds.DescribeDBSnapshotsInput{
 		DBInstanceIdentifier: aws.String(instanceID),
-		SnapshotType:         aws.String("automated"),
-		MaxRecords:           aws.Int32(20),
+		MaxRecords:           aws.Int32(100),
 		IncludeShared:        aws.Bool(true),
-		IncludePublic:        aws.Bool(true),
+	}
+	if filter.Type != "" {
+		input.SnapshotType = aws.String(filter.Type)
 	}
 
 	logger.LogDebug("Describing DB snapshots",
 		logger.String("instance_id", instanceID),
 		logger.Any("input", input))
 
-	resp, err := client.DescribeDBSnapshots(context.TODO(), input)
-	if err != nil {
-		// 详细的错误信息处理
-		return nil, fmt.Errorf("failed to describe DB snapshots: %v (instanceID: %s)", err, instanceID)
+	var snapshots []Snapshot
+	paginator := rds.NewDescribeDBSnapshotsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds.DescribeDBSnapshotsOutput, error) {
+			return paginator.NextPage(ctx)
+		})
+		if err != nil {
+			// 详细的错误信息处理
+			return nil, fmt.Errorf("failed to describe DB snapshots: %w (instanceID: %s)", err, instanceID)
+		}
+
+		for _, s := range page.DBSnapshots {
+			snapshot := toSnapshot(s)
+			if filter.Status != "" && !strings.EqualFold(snapshot.Status, filter.Status) {
+				continue
+			}
+			if snapshot.CreateTime != nil {
+				if !filter.Start.IsZero() && snapshot.CreateTime.Before(filter.Start) {
+					continue
+				}
+				if !filter.End.IsZero() && snapshot.CreateTime.After(filter.End) {
+					continue
+				}
+			}
+			snapshots = append(snapshots, snapshot)
+		}
 	}
 
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return createTime(snapshots[i]).After(createTime(snapshots[j]))
+	})
+
 	logger.LogDebug("Found snapshots",
-		logger.Int("count", len(resp.DBSnapshots)),
+		logger.Int("count", len(snapshots)),
 		logger.String("instance_id", instanceID))
+	return snapshots, nil
+}
 
-	// 获取最新快照
-	var latestSnapshot *types.DBSnapshot
-	for _, snapshot := range resp.DBSnapshots {
-		if snapshot.Status != nil && *snapshot.Status == "available" {
-			if latestSnapshot == nil || snapshot.SnapshotCreateTime.After(*latestSnapshot.SnapshotCreateTime) {
-				latestSnapshot = &snapshot
-			}
+// DescribeSnapshot 根据快照标识符或 ARN 直接查询快照，不限定实例；共享快照需使用 ARN
+func DescribeSnapshot(ctx context.Context, target Target, snapshotID string) (*Snapshot, error) {
+	client, err := createAWSClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS RDS client: %v", err)
+	}
+
+	out, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds.DescribeDBSnapshotsOutput, error) {
+		return client.DescribeDBSnapshots(ctx, &rds.DescribeDBSnapshotsInput{
+			DBSnapshotIdentifier: aws.String(snapshotID),
+			IncludeShared:        aws.Bool(true),
+		})
+	})
+	if err != nil {
+		var notFound *types.DBSnapshotNotFoundFault
+		if errors.As(err, &notFound) {
+			return nil, ErrSnapshotNotFound
 		}
+		return nil, fmt.Errorf("failed to describe DB snapshot: %w (snapshotID: %s)", err, snapshotID)
+	}
+	if len(out.DBSnapshots) == 0 {
+		return nil, ErrSnapshotNotFound
 	}
 
-	if latestSnapshot == nil {
+	snapshot := toSnapshot(out.DBSnapshots[0])
+	return &snapshot, nil
+}
+
+// GetSnapshot 根据快照标识符或 ARN 查询实例的快照，快照不存在或来源不是该实例时返回 ErrSnapshotNotFound
+func GetSnapshot(ctx context.Context, instanceID string, target Target, snapshotID string) (*Snapshot, error) {
+	snapshot, err := DescribeSnapshot(ctx, target, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.InstanceID != InstanceName(instanceID) {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+// GetLatestSnapshot 获取最新的可用自动快照，没有快照时返回 nil
+func GetLatestSnapshot(ctx context.Context, instanceID string, target Target) (*Snapshot, error) {
+	logger.LogInfo("Fetching latest snapshot info",
+		logger.String("instance_id", instanceID),
+		logger.String("region", target.Region))
+
+	// 获取最新快照
+	snapshots, err := ListSnapshots(ctx, instanceID, target, SnapshotFilter{
+		Type:   SnapshotTypeAutomated,
+		Status: SnapshotStatusAvailable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
 		logger.LogWarn("No available snapshots found",
 			logger.String("instance_id", instanceID))
-		return map[string]string{
-			"SnapshotArn":        "",
-			"SnapshotCreateTime": "",
-			"SnapshotId":         "",
-			"Status":             "",
-		}, nil
-	}
-
-	return map[string]string{
-		"SnapshotArn":        aws.ToString(latestSnapshot.DBSnapshotArn),
-		"SnapshotCreateTime": latestSnapshot.SnapshotCreateTime.String(),
-		"SnapshotId":         aws.ToString(latestSnapshot.DBSnapshotIdentifier),
-		"Status":             aws.ToString(latestSnapshot.Status),
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
+
+func createTime(s Snapshot) time.Time {
+	if s.CreateTime == nil {
+		return time.Time{}
+	}
+	return *s.CreateTime
+}
+
+func toSnapshot(s types.DBSnapshot) Snapshot {
+	return Snapshot{
+		SnapshotID:       aws.ToString(s.DBSnapshotIdentifier),
+		SnapshotArn:      aws.ToString(s.DBSnapshotArn),
+		Type:             aws.ToString(s.SnapshotType),
+		Status:           aws.ToString(s.Status),
+		CreateTime:       s.SnapshotCreateTime,
+		InstanceID:       aws.ToString(s.DBInstanceIdentifier),
+		Engine:           aws.ToString(s.Engine),
+		EngineVersion:    aws.ToString(s.EngineVersion),
+		AllocatedStorage: aws.ToInt32(s.AllocatedStorage),
+		Encrypted:        aws.ToBool(s.Encrypted),
+		KmsKeyId:         aws.ToString(s.KmsKeyId),
+	}
+}
+
+// ErrInstanceNotFound 实例不存在
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// Instance AWS RDS实例信息
+type Instance struct {
+	InstanceID       string `json:"instance_id"`
+	InstanceArn      string `json:"instance_arn"`
+	Engine           string `json:"engine"`
+	EngineVersion    string `json:"engine_version"`
+	InstanceClass    string `json:"instance_class"`
+	Status           string `json:"status"`
+	AvailabilityZone string `json:"availability_zone"`
+	AllocatedStorage int32  `json:"allocated_storage_gb"`
+}
+
+// GetInstance 通过 DescribeDBInstances 获取实例信息，instanceID 可以是实例标识符或ARN
+func GetInstance(ctx context.Context, instanceID string, target Target) (*Instance, error) {
+	client, err := createAWSClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS RDS client: %v", err)
+	}
+
+	out, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds.DescribeDBInstancesOutput, error) {
+		return client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: aws.String(instanceID),
+		})
+	})
+	if err != nil {
+		var notFound *types.DBInstanceNotFoundFault
+		if errors.As(err, &notFound) {
+			return nil, ErrInstanceNotFound
+		}
+		return nil, fmt.Errorf("failed to describe DB instance: %w", err)
+	}
+	if len(out.DBInstances) == 0 {
+		return nil, ErrInstanceNotFound
+	}
+
+	db := out.DBInstances[0]
+	return &Instance{
+		InstanceID:       aws.ToString(db.DBInstanceIdentifier),
+		InstanceArn:      aws.ToString(db.DBInstanceArn),
+		Engine:           aws.ToString(db.Engine),
+		EngineVersion:    aws.ToString(db.EngineVersion),
+		InstanceClass:    aws.ToString(db.DBInstanceClass),
+		Status:           aws.ToString(db.DBInstanceStatus),
+		AvailabilityZone: aws.ToString(db.AvailabilityZone),
+		AllocatedStorage: aws.ToInt32(db.AllocatedStorage),
 	}, nil
 }
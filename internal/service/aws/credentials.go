@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/store"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// DefaultSessionName 未配置 sessionName 时 AssumeRole 使用的会话名称
+const DefaultSessionName = "backuprds"
+
+// Credentials AWS 凭证配置，零值时使用 SDK 默认凭证链：
+// 环境变量（含 AWS_SESSION_TOKEN）、共享配置文件、Web Identity（EKS IRSA）、ECS/EC2 实例角色
+type Credentials struct {
+	// Profile 共享配置文件（~/.aws/config）中的 profile
+	Profile string
+	// RoleArn 在默认凭证之上通过 STS AssumeRole 获取临时凭证，用于访问其他账号
+	RoleArn     string
+	ExternalID  string
+	SessionName string
+}
+
+// Target 调用 AWS 接口的 region 和凭证
+type Target struct {
+	Region      string
+	Credentials Credentials
+}
+
+// TargetFor 实例所在 region 和访问实例使用的凭证
+func TargetFor(instance config.InstanceConfig) Target {
+	return Target{
+		Region: instance.Region,
+		Credentials: Credentials{
+			Profile:     instance.Profile,
+			RoleArn:     instance.RoleArn,
+			ExternalID:  instance.ExternalID,
+			SessionName: instance.SessionName,
+		},
+	}
+}
+
+// LoadConfig 加载指定 region 的 SDK 配置，配置了 RoleArn 时凭证替换为自动续期的 AssumeRole 临时凭证
+func LoadConfig(ctx context.Context, region string, creds Credentials) (aws.Config, error) {
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+	if creds.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(creds.Profile))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	Instrument(&cfg, store.ProviderAws)
+
+	if creds.RoleArn != "" {
+		sessionName := creds.SessionName
+		if sessionName == "" {
+			sessionName = DefaultSessionName
+		}
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), creds.RoleArn,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = sessionName
+				if creds.ExternalID != "" {
+					o.ExternalID = aws.String(creds.ExternalID)
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,214 @@
+package aws
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/retry"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// 导出任务状态
+const (
+	ExportStatusStarting   = "STARTING"
+	ExportStatusInProgress = "IN_PROGRESS"
+	ExportStatusComplete   = "COMPLETE"
+	ExportStatusCanceling  = "CANCELING"
+	ExportStatusCanceled   = "CANCELED"
+	ExportStatusFailed     = "FAILED"
+)
+
+var (
+	// ErrExportTaskNotFound 导出任务不存在
+	ErrExportTaskNotFound = errors.New("export task not found")
+	// ErrExportTaskNotCancellable 导出任务已结束或正在取消，无法再取消
+	ErrExportTaskNotCancellable = errors.New("export task is not in a cancellable state")
+)
+
+// ExportTask 快照导出任务状态
+type ExportTask struct {
+	ExportTaskID           string     `json:"export_task_id"`
+	SourceArn              string     `json:"source_arn"`
+	Status                 string     `json:"status"`
+	PercentProgress        int32      `json:"percent_progress"`
+	TotalExtractedDataInGB int32      `json:"total_extracted_data_in_gb"`
+	FailureCause           string     `json:"failure_cause,omitempty"`
+	WarningMessage         string     `json:"warning_message,omitempty"`
+	S3Bucket               string     `json:"s3_bucket"`
+	S3Prefix               string     `json:"s3_prefix"`
+	SnapshotTime           *time.Time `json:"snapshot_time,omitempty"`
+	TaskStartTime          *time.Time `json:"task_start_time,omitempty"`
+	TaskEndTime            *time.Time `json:"task_end_time,omitempty"`
+}
+
+// Finished 导出任务是否已结束
+func (t *ExportTask) Finished() bool {
+	return t.Status == ExportStatusComplete || t.Status == ExportStatusCanceled || t.Status == ExportStatusFailed
+}
+
+// S3Location 导出数据所在的 S3 路径，AWS 会在前缀下以任务ID建立目录
+func (t *ExportTask) S3Location() string {
+	prefix := strings.TrimSuffix(t.S3Prefix, "/")
+	if prefix == "" {
+		return fmt.Sprintf("s3://%s/%s/", t.S3Bucket, t.ExportTaskID)
+	}
+	return fmt.Sprintf("s3://%s/%s/%s/", t.S3Bucket, prefix, t.ExportTaskID)
+}
+
+// ExportTaskFilter 导出任务查询条件，零值字段不参与过滤
+type ExportTaskFilter struct {
+	ExportTaskID string
+	S3Bucket     string
+}
+
+// DescribeExportTasks 分页查询目标 region 的快照导出任务
+func DescribeExportTasks(ctx context.Context, target Target, filter ExportTaskFilter) ([]ExportTask, error) {
+	client, err := createAWSClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS RDS client: %v", err)
+	}
+
+	input := &rds.DescribeExportTasksInput{
+		MaxRecords: aws.Int32(100),
+	}
+	if filter.ExportTaskID != "" {
+		input.ExportTaskIdentifier = aws.String(filter.ExportTaskID)
+	}
+	if filter.S3Bucket != "" {
+		input.Filters = append(input.Filters, types.Filter{
+			Name:   aws.String("s3-bucket"),
+			Values: []string{filter.S3Bucket},
+		})
+	}
+
+	var tasks []ExportTask
+	paginator := rds.NewDescribeExportTasksPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := retry.DoValue(ctx, retry.OpDescribe, func(ctx context.Context) (*rds.DescribeExportTasksOutput, error) {
+			return paginator.NextPage(ctx)
+		})
+		if err != nil {
+			var notFound *types.ExportTaskNotFoundFault
+			if errors.As(err, &notFound) {
+				return nil, ErrExportTaskNotFound
+			}
+			return nil, fmt.Errorf("failed to describe export tasks: %w", err)
+		}
+		for _, t := range page.ExportTasks {
+			tasks = append(tasks, toExportTask(t))
+		}
+	}
+
+	logger.LogDebug("Described export tasks",
+		logger.String("region", target.Region),
+		logger.Int("count", len(tasks)))
+	return tasks, nil
+}
+
+// GetExportTask 查询单个导出任务
+func GetExportTask(ctx context.Context, target Target, exportTaskID string) (*ExportTask, error) {
+	tasks, err := DescribeExportTasks(ctx, target, ExportTaskFilter{ExportTaskID: exportTaskID})
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, ErrExportTaskNotFound
+	}
+	return &tasks[0], nil
+}
+
+// automatedSnapshotSuffix 自动快照标识符中实例名之后的时间部分
+var automatedSnapshotSuffix = regexp.MustCompile(`^-\d{4}-\d{2}-\d{2}-\d{2}-\d{2}$`)
+
+// SnapshotBelongsTo 根据快照 ARN 判断是否为指定实例的自动快照，自动快照的标识符形如 rds:<实例名>-YYYY-MM-DD-HH-MM；
+// 手动快照无法从名称判断，需查询快照的来源实例
+func SnapshotBelongsTo(snapshotArn, instanceID string) bool {
+	snapshotID := snapshotArn
+	if idx := strings.Index(snapshotArn, ":snapshot:"); idx >= 0 {
+		snapshotID = snapshotArn[idx+len(":snapshot:"):]
+	}
+	rest, ok := strings.CutPrefix(snapshotID, "rds:"+InstanceName(instanceID))
+	return ok && automatedSnapshotSuffix.MatchString(rest)
+}
+
+// InstanceName 从实例 ARN 中提取实例标识符，非 ARN 时原样返回
+func InstanceName(instanceID string) string {
+	if idx := strings.LastIndex(instanceID, ":db:"); idx >= 0 {
+		return instanceID[idx+len(":db:"):]
+	}
+	return instanceID
+}
+
+func toExportTask(t types.ExportTask) ExportTask {
+	return ExportTask{
+		ExportTaskID:           aws.ToString(t.ExportTaskIdentifier),
+		SourceArn:              aws.ToString(t.SourceArn),
+		Status:                 aws.ToString(t.Status),
+		PercentProgress:        aws.ToInt32(t.PercentProgress),
+		TotalExtractedDataInGB: aws.ToInt32(t.TotalExtractedDataInGB),
+		FailureCause:           aws.ToString(t.FailureCause),
+		WarningMessage:         aws.ToString(t.WarningMessage),
+		S3Bucket:               aws.ToString(t.S3Bucket),
+		S3Prefix:               aws.ToString(t.S3Prefix),
+		SnapshotTime:           t.SnapshotTime,
+		TaskStartTime:          t.TaskStartTime,
+		TaskEndTime:            t.TaskEndTime,
+	}
+}
+
+// CancelExportTask 取消快照导出任务，返回取消后的任务状态
+func CancelExportTask(ctx context.Context, target Target, exportTaskID string) (*ExportTask, error) {
+	client, err := createAWSClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS RDS client: %v", err)
+	}
+
+	attempt := 0
+	return retry.DoValue(ctx, retry.OpExport, func(ctx context.Context) (*ExportTask, error) {
+		attempt++
+		out, err := client.CancelExportTask(ctx, &rds.CancelExportTaskInput{
+			ExportTaskIdentifier: aws.String(exportTaskID),
+		})
+		if err == nil {
+			task := toExportTask(types.ExportTask{
+				ExportTaskIdentifier:   out.ExportTaskIdentifier,
+				SourceArn:              out.SourceArn,
+				Status:                 out.Status,
+				PercentProgress:        out.PercentProgress,
+				TotalExtractedDataInGB: out.TotalExtractedDataInGB,
+				FailureCause:           out.FailureCause,
+				WarningMessage:         out.WarningMessage,
+				S3Bucket:               out.S3Bucket,
+				S3Prefix:               out.S3Prefix,
+				SnapshotTime:           out.SnapshotTime,
+				TaskStartTime:          out.TaskStartTime,
+				TaskEndTime:            out.TaskEndTime,
+			})
+			return &task, nil
+		}
+
+		var notFound *types.ExportTaskNotFoundFault
+		if errors.As(err, &notFound) {
+			return nil, ErrExportTaskNotFound
+		}
+		var invalidState *types.InvalidExportTaskStateFault
+		if errors.As(err, &invalidState) {
+			// 上一次调用可能已经生效，重试时任务处于取消中或已取消视为取消成功
+			if attempt > 1 {
+				task, getErr := GetExportTask(ctx, target, exportTaskID)
+				if getErr == nil && (task.Status == ExportStatusCanceling || task.Status == ExportStatusCanceled) {
+					return task, nil
+				}
+			}
+			return nil, ErrExportTaskNotCancellable
+		}
+		return nil, fmt.Errorf("failed to cancel export task: %w", err)
+	})
+}
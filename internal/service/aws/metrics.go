@@ -0,0 +1,26 @@
+package aws
+
+import (
+	"backuprds/internal/metrics"
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Instrument 为 SDK 配置创建的客户端记录每次接口调用，中间件位于重试之后，SDK 内部的每次重试单独计数；
+// provider 为指标中的云厂商标签，S3 兼容存储使用存储类型
+func Instrument(cfg *aws.Config, provider string) {
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("BackuprdsMetrics",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, md, err := next.HandleFinalize(ctx, in)
+				operation := awsmiddleware.GetServiceID(ctx) + "." + awsmiddleware.GetOperationName(ctx)
+				metrics.ObserveCloudCall(provider, operation, start, err)
+				return out, md, err
+			}), middleware.After)
+	})
+}
@@ -0,0 +1,73 @@
+// Package clientpool 缓存云厂商 SDK 客户端，相同 region 和凭证的调用共享同一个客户端，
+// 避免每次请求重复加载配置和凭证
+package clientpool
+
+import "sync"
+
+// Pool 按键缓存客户端，键通常由 region 和凭证配置组成，创建失败时不缓存
+type Pool[K comparable, V any] struct {
+	mu      sync.Mutex
+	clients map[K]V
+	// pending 正在创建的客户端，同一个键的并发调用等待同一次创建
+	pending map[K]*call[V]
+	// generation 每次 Reset 递增，Reset 之前开始创建的客户端不再缓存
+	generation uint64
+	create     func(K) (V, error)
+}
+
+// call 一次客户端创建，done 关闭后 client 和 err 可读
+type call[V any] struct {
+	done   chan struct{}
+	client V
+	err    error
+}
+
+// New 创建客户端池，create 在键第一次使用时调用
+func New[K comparable, V any](create func(K) (V, error)) *Pool[K, V] {
+	return &Pool[K, V]{
+		clients: make(map[K]V),
+		pending: make(map[K]*call[V]),
+		create:  create,
+	}
+}
+
+// Get 返回键对应的客户端，不存在时创建并缓存；创建在锁外进行，不阻塞其他键的调用
+func (p *Pool[K, V]) Get(key K) (V, error) {
+	p.mu.Lock()
+	if client, ok := p.clients[key]; ok {
+		p.mu.Unlock()
+		return client, nil
+	}
+	if c, ok := p.pending[key]; ok {
+		p.mu.Unlock()
+		<-c.done
+		return c.client, c.err
+	}
+	c := &call[V]{done: make(chan struct{})}
+	p.pending[key] = c
+	generation := p.generation
+	p.mu.Unlock()
+
+	c.client, c.err = p.create(key)
+
+	p.mu.Lock()
+	if p.pending[key] == c {
+		delete(p.pending, key)
+	}
+	if c.err == nil && generation == p.generation {
+		p.clients[key] = c.client
+	}
+	p.mu.Unlock()
+	close(c.done)
+
+	return c.client, c.err
+}
+
+// Reset 清空缓存的客户端，配置变更后调用
+func (p *Pool[K, V]) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clients = make(map[K]V)
+	p.pending = make(map[K]*call[V])
+	p.generation++
+}
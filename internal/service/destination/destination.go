@@ -0,0 +1,218 @@
+// Package destination 定义备份存储目标接口，支持 AWS S3、S3 兼容存储、阿里云 OSS 和本地目录
+package destination
+
+import (
+	"backuprds/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 存储目标类型
+const (
+	TypeS3           = "s3"
+	TypeS3Compatible = "s3compatible"
+	TypeOSS          = "oss"
+	TypeLocal        = "local"
+)
+
+// 对象元数据和本地校验和文件扩展名中保存校验和的键名
+const (
+	TagSHA256 = "sha256"
+	TagMD5    = "md5"
+)
+
+// LegacyName 未给实例指定存储目标时使用 rds.aliyun.s3export 配置的 S3 目标
+const LegacyName = "s3export"
+
+// partSuffix 上传中的暂存对象和临时文件的后缀，校验通过后才移动到最终路径，避免留下不完整的备份
+const partSuffix = ".part"
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("object not found")
+	// ErrSizeMismatch 上传的字节数与源端声明的大小不一致
+	ErrSizeMismatch = errors.New("uploaded size does not match expected size")
+	// ErrUnknownDestination 配置中没有该存储目标
+	ErrUnknownDestination = errors.New("unknown destination")
+	// ErrNotConfigured 实例未指定存储目标且 s3export 未配置
+	ErrNotConfigured = errors.New("destination is not configured")
+)
+
+// DownloadError 读取备份下载流失败，各存储目标的上传随之中止
+type DownloadError struct {
+	Err error
+}
+
+func (e *DownloadError) Error() string {
+	return "failed to download backup: " + e.Err.Error()
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// Object 存储目标中的对象
+type Object struct {
+	Key      string
+	Location string
+	Size     int64
+	// SHA256 对象保存的校验和，没有校验和时为空
+	SHA256 string
+}
+
+// Checksums 对象的校验和（十六进制）
+type Checksums struct {
+	SHA256 string
+	MD5    string
+}
+
+// Destination 备份存储目标
+type Destination interface {
+	// Name 配置中的目标名称
+	Name() string
+	// Type 目标类型
+	Type() string
+	// Bucket 存储桶名称，本地目录为目录路径
+	Bucket() string
+	// Region 存储桶所在 region，没有 region 概念时为空
+	Region() string
+	// Stat 查询对象及其校验和，不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Put 流式写入对象，ctx 取消时中止上传
+	Put(ctx context.Context, key string, r io.Reader) (*Object, error)
+	// Promote 确认暂存对象的大小为 size 后，将其连同校验和一起写入 key，
+	// key 上不会出现没有校验和的对象；暂存对象由调用方删除
+	Promote(ctx context.Context, staging, key string, size int64, sums Checksums) (*Object, error)
+	// Delete 删除对象
+	Delete(ctx context.Context, key string) error
+}
+
+// New 根据配置创建存储目标
+func New(name string, c config.DestinationConfig) (Destination, error) {
+	switch c.Type {
+	case TypeS3, "":
+		if c.Bucket == "" || c.Region == "" {
+			return nil, fmt.Errorf("destination %s: bucket and region are required", name)
+		}
+		return newS3(name, c), nil
+	case TypeS3Compatible:
+		if c.Bucket == "" || c.Endpoint == "" {
+			return nil, fmt.Errorf("destination %s: bucket and endpoint are required", name)
+		}
+		return newS3(name, c), nil
+	case TypeOSS:
+		if c.Bucket == "" || c.Endpoint == "" {
+			return nil, fmt.Errorf("destination %s: bucket and endpoint are required", name)
+		}
+		return newOSS(name, c), nil
+	case TypeLocal:
+		if c.Path == "" {
+			return nil, fmt.Errorf("destination %s: path is required", name)
+		}
+		return newLocal(name, c), nil
+	}
+	return nil, fmt.Errorf("destination %s: invalid type %q", name, c.Type)
+}
+
+// Get 根据名称创建存储目标，LegacyName 对应 rds.aliyun.s3export
+func Get(cfg *config.Config, name string) (Destination, error) {
+	if name == LegacyName {
+		s3Config := cfg.RDS.Aliyun.S3Export
+		if s3Config.Region == "" || s3Config.BucketName == "" {
+			return nil, ErrNotConfigured
+		}
+		return newS3(LegacyName, config.DestinationConfig{
+			Type:   TypeS3,
+			Region: s3Config.Region,
+			Bucket: s3Config.BucketName,
+		}), nil
+	}
+
+	c, ok := cfg.Destinations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDestination, name)
+	}
+	return New(name, c)
+}
+
+// NamesFor 实例使用的存储目标名称，依次取 destinations、destination，均为空时为 LegacyName
+func NamesFor(instanceConfig config.InstanceConfig) []string {
+	if len(instanceConfig.Destinations) > 0 {
+		return instanceConfig.Destinations
+	}
+	if instanceConfig.Destination != "" {
+		return []string{instanceConfig.Destination}
+	}
+	return []string{LegacyName}
+}
+
+// GetAll 根据名称创建多个存储目标，重复的名称只保留一个
+func GetAll(cfg *config.Config, names []string) ([]Destination, error) {
+	seen := make(map[string]bool, len(names))
+	dests := make([]Destination, 0, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		dest, err := Get(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		dests = append(dests, dest)
+	}
+	return dests, nil
+}
+
+// ForInstance 创建实例使用的存储目标
+func ForInstance(cfg *config.Config, instanceConfig config.InstanceConfig) ([]Destination, error) {
+	return GetAll(cfg, NamesFor(instanceConfig))
+}
+
+// Validate 检查配置中的存储目标和实例引用的目标
+func Validate(cfg *config.Config) error {
+	for name, c := range cfg.Destinations {
+		if _, err := New(name, c); err != nil {
+			return err
+		}
+	}
+	for env, instanceConfig := range cfg.RDS.Aliyun.Instances {
+		for _, name := range NamesFor(instanceConfig) {
+			if name == LegacyName {
+				continue
+			}
+			if _, ok := cfg.Destinations[name]; !ok {
+				return fmt.Errorf("instance %s: %w: %s", env, ErrUnknownDestination, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ResetClients 清空缓存的 S3 和 OSS 客户端，配置重新加载后使用新的凭证和上传重试策略
+func ResetClients() {
+	s3Clients.Reset()
+	ossBuckets.Reset()
+}
+
+// credentialsFromEnv 从配置指定的环境变量读取访问密钥，未指定时使用默认的环境变量
+func credentialsFromEnv(c config.DestinationConfig, defaultKeyEnv, defaultSecretEnv string) (string, string, error) {
+	keyEnv, secretEnv := c.AccessKeyEnv, c.SecretKeyEnv
+	if keyEnv == "" {
+		keyEnv = defaultKeyEnv
+	}
+	if secretEnv == "" {
+		secretEnv = defaultSecretEnv
+	}
+
+	accessKey := os.Getenv(keyEnv)
+	secretKey := os.Getenv(secretEnv)
+	if accessKey == "" || secretKey == "" {
+		return "", "", fmt.Errorf("missing required environment variables: %s or %s", keyEnv, secretEnv)
+	}
+	return accessKey, secretKey, nil
+}
@@ -0,0 +1,147 @@
+package destination
+
+import (
+	"backuprds/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localDestination 本地目录或挂载的 NFS 目录
+type localDestination struct {
+	name string
+	root string
+}
+
+func newLocal(name string, c config.DestinationConfig) *localDestination {
+	root, err := filepath.Abs(c.Path)
+	if err != nil {
+		root = filepath.Clean(c.Path)
+	}
+	return &localDestination{name: name, root: root}
+}
+
+func (d *localDestination) Name() string   { return d.name }
+func (d *localDestination) Type() string   { return TypeLocal }
+func (d *localDestination) Bucket() string { return d.root }
+func (d *localDestination) Region() string { return "" }
+
+// path 将对象 key 转换为 root 下的文件路径，拒绝跳出 root 的 key
+func (d *localDestination) path(key string) (string, error) {
+	p := filepath.Join(d.root, filepath.FromSlash(key))
+	if p == d.root || !strings.HasPrefix(p, d.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key %q for destination %s", key, d.name)
+	}
+	return p, nil
+}
+
+func (d *localDestination) Stat(_ context.Context, key string) (*Object, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验和文件格式为 "<sha256>  <文件名>"，不存在时视为没有校验和
+	var sha256 string
+	if line, err := os.ReadFile(p + "." + TagSHA256); err == nil {
+		if fields := strings.Fields(string(line)); len(fields) > 0 {
+			sha256 = fields[0]
+		}
+	}
+	return &Object{Key: key, Location: "file://" + p, Size: info.Size(), SHA256: sha256}, nil
+}
+
+func (d *localDestination) Put(_ context.Context, key string, r io.Reader) (*Object, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	tmp := p + partSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to sync file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to close file: %v", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to rename file: %v", err)
+	}
+	return &Object{Key: key, Location: "file://" + p}, nil
+}
+
+// Promote 在备份文件旁写入 .sha256 和 .md5 文件，格式与 sha256sum/md5sum 输出一致，可直接用 -c 校验；
+// 校验和文件先于备份文件写入，备份文件出现在最终路径时校验和已存在
+func (d *localDestination) Promote(_ context.Context, staging, key string, size int64, sums Checksums) (*Object, error) {
+	src, err := d.path(staging)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat staged file: %v", err)
+	}
+	if info.Size() != size {
+		return nil, fmt.Errorf("%w: staged file has %d bytes, expected %d", ErrSizeMismatch, info.Size(), size)
+	}
+
+	base := filepath.Base(p)
+	for ext, sum := range map[string]string{TagSHA256: sums.SHA256, TagMD5: sums.MD5} {
+		line := fmt.Sprintf("%s  %s\n", sum, base)
+		if err := os.WriteFile(p+"."+ext, []byte(line), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(src, p); err != nil {
+		return nil, fmt.Errorf("failed to rename file: %v", err)
+	}
+	return &Object{Key: key, Location: "file://" + p, Size: size, SHA256: sums.SHA256}, nil
+}
+
+func (d *localDestination) Delete(_ context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range []string{p, p + "." + TagSHA256, p + "." + TagMD5} {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package destination
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/metrics"
+	"backuprds/internal/retry"
+	"backuprds/internal/service/clientpool"
+	"backuprds/internal/store"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	// OSS 单次 PutObject 最大 5GB，备份文件使用分片上传
+	ossPartSize = 100 * 1024 * 1024
+	// CopyObject 单次复制的上限，更大的对象分片复制
+	ossMaxCopySize  = 1 << 30
+	ossCopyPartSize = 1 << 30
+)
+
+// ossDestination 阿里云 OSS
+type ossDestination struct {
+	name string
+	cfg  config.DestinationConfig
+}
+
+func newOSS(name string, c config.DestinationConfig) *ossDestination {
+	return &ossDestination{name: name, cfg: c}
+}
+
+func (d *ossDestination) Name() string   { return d.name }
+func (d *ossDestination) Type() string   { return TypeOSS }
+func (d *ossDestination) Bucket() string { return d.cfg.Bucket }
+func (d *ossDestination) Region() string { return d.cfg.Region }
+
+// ossBuckets 按存储目标配置缓存的 OSS 客户端
+var ossBuckets = clientpool.New(func(c config.DestinationConfig) (*oss.Bucket, error) {
+	accessKey, secretKey, err := credentialsFromEnv(c, "ALIBABA_CLOUD_ACCESS_KEY_ID", "ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := oss.New(c.Endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OSS client: %v", err)
+	}
+	return client.Bucket(c.Bucket)
+})
+
+func (d *ossDestination) bucket() (*oss.Bucket, error) {
+	return ossBuckets.Get(d.cfg)
+}
+
+// ossError 为 OSS 服务端错误提供错误码和状态码，用于判断能否重试
+type ossError struct {
+	oss.ServiceError
+}
+
+func (e ossError) ErrorCode() string   { return e.Code }
+func (e ossError) HTTPStatusCode() int { return e.StatusCode }
+func (e ossError) Unwrap() error       { return e.ServiceError }
+
+// ossCall 按操作的重试策略调用 OSS 接口，operation 为指标中的接口名称
+func ossCall(ctx context.Context, op, operation string, fn func() error) error {
+	return retry.Do(ctx, op, func(context.Context) error {
+		start := time.Now()
+		err := fn()
+		metrics.ObserveCloudCall(store.ProviderAliyun, "OSS."+operation, start, err)
+		var svcErr oss.ServiceError
+		if errors.As(err, &svcErr) {
+			return ossError{svcErr}
+		}
+		return err
+	})
+}
+
+func (d *ossDestination) location(key string) string {
+	return fmt.Sprintf("oss://%s/%s", d.cfg.Bucket, key)
+}
+
+func (d *ossDestination) Stat(ctx context.Context, key string) (*Object, error) {
+	bucket, err := d.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := d.head(ctx, bucket, key)
+	if err != nil {
+		var svcErr oss.ServiceError
+		if errors.As(err, &svcErr) && svcErr.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get object meta: %v", err)
+	}
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+
+	// 没有校验和元数据的对象视为未校验
+	sha256 := header.Get(oss.HTTPHeaderOssMetaPrefix + TagSHA256)
+	return &Object{Key: key, Location: d.location(key), Size: size, SHA256: sha256}, nil
+}
+
+// head 查询对象的大小和用户元数据
+func (d *ossDestination) head(ctx context.Context, bucket *oss.Bucket, key string) (http.Header, error) {
+	var header http.Header
+	err := ossCall(ctx, retry.OpDescribe, "HeadObject", func() error {
+		var err error
+		header, err = bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+		return err
+	})
+	return header, err
+}
+
+// Put 按 ossPartSize 分片顺序上传，失败时取消分片上传
+func (d *ossDestination) Put(ctx context.Context, key string, r io.Reader) (*Object, error) {
+	bucket, err := d.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	var imur oss.InitiateMultipartUploadResult
+	err = ossCall(ctx, retry.OpUpload, "InitiateMultipartUpload", func() error {
+		imur, err = bucket.InitiateMultipartUpload(key, oss.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initiate multipart upload: %v", err)
+	}
+
+	var parts []oss.UploadPart
+	buf := make([]byte, ossPartSize)
+	for partNumber := 1; ; partNumber++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 || partNumber == 1 {
+			// 分片在内存中，失败时可以重新上传
+			var part oss.UploadPart
+			err := ossCall(ctx, retry.OpUpload, "UploadPart", func() error {
+				var err error
+				part, err = bucket.UploadPart(imur, bytes.NewReader(buf[:n]), int64(n), partNumber, oss.WithContext(ctx))
+				return err
+			})
+			if err != nil {
+				bucket.AbortMultipartUpload(imur)
+				return nil, fmt.Errorf("failed to upload part %d to OSS: %v", partNumber, err)
+			}
+			parts = append(parts, part)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			bucket.AbortMultipartUpload(imur)
+			return nil, readErr
+		}
+	}
+
+	err = ossCall(ctx, retry.OpUpload, "CompleteMultipartUpload", func() error {
+		_, err := bucket.CompleteMultipartUpload(imur, parts, oss.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		bucket.AbortMultipartUpload(imur)
+		return nil, fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return &Object{Key: key, Location: d.location(key)}, nil
+}
+
+// Promote 与 S3 相同，通过服务端复制创建最终对象并写入校验和元数据 x-oss-meta-sha256/md5，
+// 超过 CopyObject 上限的对象分片复制
+func (d *ossDestination) Promote(ctx context.Context, staging, key string, size int64, sums Checksums) (*Object, error) {
+	bucket, err := d.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := d.head(ctx, bucket, staging)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged object meta: %v", err)
+	}
+	if n, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64); n != size {
+		return nil, fmt.Errorf("%w: staged object has %d bytes, expected %d", ErrSizeMismatch, n, size)
+	}
+
+	meta := []oss.Option{oss.Meta(TagSHA256, sums.SHA256), oss.Meta(TagMD5, sums.MD5), oss.WithContext(ctx)}
+	if size <= ossMaxCopySize {
+		err = ossCall(ctx, retry.OpUpload, "CopyObject", func() error {
+			_, err := bucket.CopyObject(staging, key, append(meta, oss.MetadataDirective(oss.MetaReplace))...)
+			return err
+		})
+	} else {
+		err = d.multipartCopy(ctx, bucket, staging, key, size, meta)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy staged object: %v", err)
+	}
+	return &Object{Key: key, Location: d.location(key), Size: size, SHA256: sums.SHA256}, nil
+}
+
+// multipartCopy 以 ossCopyPartSize 分片顺序复制 staging 到 key，失败时取消分片上传
+func (d *ossDestination) multipartCopy(ctx context.Context, bucket *oss.Bucket, staging, key string, size int64, meta []oss.Option) error {
+	var imur oss.InitiateMultipartUploadResult
+	err := ossCall(ctx, retry.OpUpload, "InitiateMultipartUpload", func() error {
+		var err error
+		imur, err = bucket.InitiateMultipartUpload(key, meta...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var parts []oss.UploadPart
+	for start, partNumber := int64(0), 1; start < size; start, partNumber = start+ossCopyPartSize, partNumber+1 {
+		partSize := min(int64(ossCopyPartSize), size-start)
+		var part oss.UploadPart
+		err := ossCall(ctx, retry.OpUpload, "UploadPartCopy", func() error {
+			var err error
+			part, err = bucket.UploadPartCopy(imur, d.cfg.Bucket, staging, start, partSize, partNumber, oss.WithContext(ctx))
+			return err
+		})
+		if err != nil {
+			bucket.AbortMultipartUpload(imur)
+			return fmt.Errorf("failed to copy part %d: %v", partNumber, err)
+		}
+		parts = append(parts, part)
+	}
+
+	err = ossCall(ctx, retry.OpUpload, "CompleteMultipartUpload", func() error {
+		_, err := bucket.CompleteMultipartUpload(imur, parts, oss.WithContext(ctx))
+		return err
+	})
+	if err != nil {
+		bucket.AbortMultipartUpload(imur)
+		return err
+	}
+	return nil
+}
+
+func (d *ossDestination) Delete(ctx context.Context, key string) error {
+	bucket, err := d.bucket()
+	if err != nil {
+		return err
+	}
+	return ossCall(ctx, retry.OpUpload, "DeleteObject", func() error {
+		return bucket.DeleteObject(key, oss.WithContext(ctx))
+	})
+}
@@ -0,0 +1,313 @@
+package destination
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/retry"
+	awsclient "backuprds/internal/service/aws"
+	"backuprds/internal/service/clientpool"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// S3 兼容存储未指定 region 时使用的签名 region
+	defaultCompatibleRegion = "us-east-1"
+	s3PartSize              = 200 * 1024 * 1024
+	s3Concurrency           = 10
+	// CopyObject 单次复制的上限，更大的对象分片复制
+	s3MaxCopySize  = 5 << 30
+	s3CopyPartSize = 1 << 30
+)
+
+// s3Destination AWS S3 或 MinIO/Ceph 等 S3 兼容存储
+type s3Destination struct {
+	name string
+	cfg  config.DestinationConfig
+}
+
+func newS3(name string, c config.DestinationConfig) *s3Destination {
+	if c.Type == "" {
+		c.Type = TypeS3
+	}
+	if c.Type == TypeS3Compatible && c.Region == "" {
+		c.Region = defaultCompatibleRegion
+	}
+	return &s3Destination{name: name, cfg: c}
+}
+
+func (d *s3Destination) Name() string   { return d.name }
+func (d *s3Destination) Type() string   { return d.cfg.Type }
+func (d *s3Destination) Bucket() string { return d.cfg.Bucket }
+func (d *s3Destination) Region() string { return d.cfg.Region }
+
+// s3Clients 按存储目标配置缓存的 S3 客户端
+var s3Clients = clientpool.New(newS3Client)
+
+func (d *s3Destination) client() (*s3.Client, error) {
+	return s3Clients.Get(d.cfg)
+}
+
+// newS3Client 创建 S3 客户端，S3 兼容存储使用自定义 endpoint，可选 path-style 访问。
+// 上传流只能读取一次，无法在外层重试，由 SDK 按 upload 策略重试单个请求（分片已缓存在内存中）
+func newS3Client(c config.DestinationConfig) (*s3.Client, error) {
+	cfg, err := loadS3Config(c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := retry.For(retry.OpUpload)
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if c.Endpoint != "" {
+			o.BaseEndpoint = aws.String(c.Endpoint)
+		}
+		o.UsePathStyle = c.PathStyle
+		o.Retryer = awsretry.NewStandard(func(so *awsretry.StandardOptions) {
+			so.MaxAttempts = policy.MaxAttempts
+			so.MaxBackoff = policy.MaxDelay
+			so.Backoff = policy
+		})
+	}), nil
+}
+
+// loadS3Config AWS S3 未指定访问密钥环境变量时使用 AWS 默认凭证链（可选 profile 和 AssumeRole），
+// 否则从环境变量读取静态密钥；客户端在请求之间共享，加载配置不使用单个请求的 context
+func loadS3Config(c config.DestinationConfig) (aws.Config, error) {
+	if c.Type == TypeS3 && c.AccessKeyEnv == "" && c.SecretKeyEnv == "" {
+		return awsclient.LoadConfig(context.Background(), c.Region, awsclient.Credentials{
+			Profile:     c.Profile,
+			RoleArn:     c.RoleArn,
+			ExternalID:  c.ExternalID,
+			SessionName: c.SessionName,
+		})
+	}
+
+	accessKey, secretKey, err := credentialsFromEnv(c, "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithRegion(c.Region),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			accessKey,
+			secretKey,
+			"", // token可以为空
+		)),
+	)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load SDK config: %v", err)
+	}
+	provider := store.ProviderAws
+	if c.Type == TypeS3Compatible {
+		provider = TypeS3Compatible
+	}
+	awsclient.Instrument(&cfg, provider)
+	return cfg, nil
+}
+
+func (d *s3Destination) location(key string) string {
+	if d.cfg.Type == TypeS3Compatible {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(d.cfg.Endpoint, "/"), d.cfg.Bucket, key)
+	}
+	return fmt.Sprintf("s3://%s/%s", d.cfg.Bucket, key)
+}
+
+func (d *s3Destination) Stat(ctx context.Context, key string) (*Object, error) {
+	client, err := d.client()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to head object: %v", err)
+	}
+
+	// 没有校验和元数据的对象视为未校验
+	return &Object{
+		Key:      key,
+		Location: d.location(key),
+		Size:     aws.ToInt64(out.ContentLength),
+		SHA256:   out.Metadata[TagSHA256],
+	}, nil
+}
+
+// Put 流式分片上传
+func (d *s3Destination) Put(ctx context.Context, key string, r io.Reader) (*Object, error) {
+	client, err := d.client()
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = s3PartSize
+		u.Concurrency = s3Concurrency
+		u.LeavePartsOnError = false
+	})
+	result, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(d.cfg.Bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to S3: %v", err)
+	}
+
+	location := result.Location
+	if location == "" {
+		location = d.location(key)
+	}
+	return &Object{Key: key, Location: location}, nil
+}
+
+// Promote 用户元数据只能在创建对象时写入，而校验和要等流读完才能得到，因此上传到暂存对象后
+// 通过服务端复制创建最终对象，复制时写入校验和元数据 x-amz-meta-sha256/md5；超过 CopyObject 上限的对象分片复制
+func (d *s3Destination) Promote(ctx context.Context, staging, key string, size int64, sums Checksums) (*Object, error) {
+	client, err := d.client()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.cfg.Bucket),
+		Key:    aws.String(staging),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head staged object: %v", err)
+	}
+	if n := aws.ToInt64(head.ContentLength); n != size {
+		return nil, fmt.Errorf("%w: staged object has %d bytes, expected %d", ErrSizeMismatch, n, size)
+	}
+
+	metadata := map[string]string{TagSHA256: sums.SHA256, TagMD5: sums.MD5}
+	source := copySource(d.cfg.Bucket, staging)
+	if size <= s3MaxCopySize {
+		_, err = client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:            aws.String(d.cfg.Bucket),
+			Key:               aws.String(key),
+			CopySource:        aws.String(source),
+			MetadataDirective: types.MetadataDirectiveReplace,
+			Metadata:          metadata,
+		})
+	} else {
+		err = d.multipartCopy(ctx, client, source, key, size, metadata)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy staged object: %v", err)
+	}
+	return &Object{Key: key, Location: d.location(key), Size: size, SHA256: sums.SHA256}, nil
+}
+
+// multipartCopy 以 s3CopyPartSize 分片并发复制 source 到 key，失败时取消分片上传
+func (d *s3Destination) multipartCopy(ctx context.Context, client *s3.Client, source, key string, size int64, metadata map[string]string) error {
+	created, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(d.cfg.Bucket),
+		Key:      aws.String(key),
+		Metadata: metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	parts := make([]types.CompletedPart, (size+s3CopyPartSize-1)/s3CopyPartSize)
+	sem := make(chan struct{}, s3Concurrency)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := range parts {
+		start := int64(i) * s3CopyPartSize
+		end := min(start+s3CopyPartSize, size) - 1
+		partNumber := aws.Int32(int32(i + 1))
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			out, err := client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+				Bucket:          aws.String(d.cfg.Bucket),
+				Key:             aws.String(key),
+				UploadId:        created.UploadId,
+				PartNumber:      partNumber,
+				CopySource:      aws.String(source),
+				CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to copy part %d: %v", i+1, err)
+				}
+				mu.Unlock()
+				return
+			}
+			parts[i] = types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: partNumber}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		_, firstErr = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(d.cfg.Bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if firstErr != nil {
+		// ctx 可能已取消，取消分片上传使用独立的 context
+		client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(d.cfg.Bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return firstErr
+	}
+	return nil
+}
+
+// copySource CopySource 参数，key 的各段需要 URL 编码
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
+func (d *s3Destination) Delete(ctx context.Context, key string) error {
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
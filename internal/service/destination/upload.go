@@ -0,0 +1,267 @@
+package destination
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/metrics"
+	"backuprds/internal/service/download"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// fanoutBufferSize 每次从下载流读取并分发给各存储目标的字节数
+	fanoutBufferSize = 1 << 20
+	// cleanupTimeout 删除暂存对象的超时时间
+	cleanupTimeout = time.Minute
+)
+
+// errUploadStopped 存储目标的 Put 已返回，不再接收数据
+var errUploadStopped = errors.New("upload stopped")
+
+// UploadResult 单个存储目标的上传结果，Err 不为空表示该目标上传失败
+type UploadResult struct {
+	Destination string
+	Bucket      string
+	Region      string
+	Key         string
+	Location    string
+	Size        int64
+	SHA256      string
+	MD5         string
+	Err         error
+}
+
+// checksumReader 统计已读取的字节数并同时计算 SHA-256 和 MD5
+type checksumReader struct {
+	r      io.Reader
+	n      int64
+	sha256 hash.Hash
+	md5    hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	return &checksumReader{r: r, sha256: sha256.New(), md5: md5.New()}
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.n += int64(n)
+		c.sha256.Write(p[:n])
+		c.md5.Write(p[:n])
+	}
+	return n, err
+}
+
+// target 一个存储目标的上传状态，pw 为 nil 表示该目标已不再接收数据
+type target struct {
+	dest Destination
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+// Upload 将一次下载的备份文件同时流式写入多个存储目标的暂存对象（key 加 .part 后缀），写入过程中只计算一次校验和，
+// 校验大小后连同校验和一起移动到 key。单个目标失败不影响其他目标，结果按 dests 的顺序返回；
+// 字节数与文件大小或 expectedSize（大于0时）不一致时删除暂存对象，结果为 ErrSizeMismatch，key 上不会出现不完整的对象；
+// ctx 取消时各目标的上传中止
+func Upload(ctx context.Context, dests []Destination, file download.File, key string, expectedSize int64) []*UploadResult {
+	start := time.Now()
+	staging := key + partSuffix
+	targets := make([]*target, len(dests))
+	for i, dest := range dests {
+		logger.LogInfo("Starting upload",
+			logger.String("destination", dest.Name()),
+			logger.String("bucket", dest.Bucket()),
+			logger.String("key", key),
+			logger.String("region", dest.Region()))
+
+		pr, pw := io.Pipe()
+		t := &target{dest: dest, pw: pw, done: make(chan struct{})}
+		targets[i] = t
+		go func() {
+			defer close(t.done)
+			_, t.err = t.dest.Put(ctx, staging, pr)
+			// Put 提前返回时让分发端的写入立即失败
+			pr.CloseWithError(errUploadStopped)
+		}()
+	}
+
+	body := newChecksumReader(file)
+	readErr := fanout(body, targets)
+	for _, t := range targets {
+		<-t.done
+	}
+	elapsed := time.Since(start)
+
+	var sizeErr error
+	if readErr == nil {
+		sizeErr = verifySize(body.n, file.Size(), expectedSize)
+	}
+	sums := Checksums{
+		SHA256: hex.EncodeToString(body.sha256.Sum(nil)),
+		MD5:    hex.EncodeToString(body.md5.Sum(nil)),
+	}
+
+	results := make([]*UploadResult, len(targets))
+	for i, t := range targets {
+		results[i] = finish(ctx, t, key, body.n, sums, readErr, sizeErr, file.Retries(), elapsed)
+	}
+	return results
+}
+
+// fanout 把 r 中的数据依次分发给仍在接收的目标，各目标并发写入，整体速度取决于最慢的目标；
+// 全部目标停止接收时提前返回
+func fanout(r io.Reader, targets []*target) error {
+	buf := make([]byte, fanoutBufferSize)
+	active := len(targets)
+
+	for active > 0 {
+		n, err := r.Read(buf)
+		if n > 0 {
+			var wg sync.WaitGroup
+			for _, t := range targets {
+				if t.pw == nil {
+					continue
+				}
+				wg.Add(1)
+				go func(t *target) {
+					defer wg.Done()
+					if _, werr := t.pw.Write(buf[:n]); werr != nil {
+						t.pw = nil
+					}
+				}(t)
+			}
+			wg.Wait()
+
+			active = 0
+			for _, t := range targets {
+				if t.pw != nil {
+					active++
+				}
+			}
+		}
+
+		if err == io.EOF {
+			closeTargets(targets, nil)
+			return nil
+		}
+		if err != nil {
+			closeTargets(targets, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// closeTargets 结束仍在接收的目标的输入，err 不为空时目标的 Put 以该错误失败
+func closeTargets(targets []*target, err error) {
+	for _, t := range targets {
+		if t.pw == nil {
+			continue
+		}
+		if err != nil {
+			t.pw.CloseWithError(err)
+		} else {
+			t.pw.Close()
+		}
+		t.pw = nil
+	}
+}
+
+// finish 校验暂存对象后连同校验和移动到 key，生成该目标的上传结果；readErr 为下载流的错误，
+// 此时目标的 Put 已随之失败，结果为 DownloadError；elapsed 为下载和全部目标写入的耗时
+func finish(ctx context.Context, t *target, key string, n int64, sums Checksums, readErr, sizeErr error, retries int, elapsed time.Duration) *UploadResult {
+	dest := t.dest
+	staging := key + partSuffix
+	result := &UploadResult{
+		Destination: dest.Name(),
+		Bucket:      dest.Bucket(),
+		Region:      dest.Region(),
+		Key:         key,
+	}
+
+	if readErr != nil {
+		removeStaging(dest, staging)
+		result.Err = &DownloadError{Err: readErr}
+		return result
+	}
+
+	if t.err != nil {
+		logger.LogError("Failed to upload backup",
+			logger.Error(t.err),
+			logger.String("destination", dest.Name()),
+			logger.String("key", key))
+		removeStaging(dest, staging)
+		result.Err = t.err
+		return result
+	}
+
+	if sizeErr != nil {
+		logger.LogError("Uploaded backup is incomplete, removing staged object",
+			logger.Error(sizeErr),
+			logger.String("destination", dest.Name()),
+			logger.String("key", staging))
+		removeStaging(dest, staging)
+		result.Err = sizeErr
+		return result
+	}
+
+	obj, err := dest.Promote(ctx, staging, key, n, sums)
+	if err != nil {
+		logger.LogError("Failed to promote staged backup",
+			logger.Error(err),
+			logger.String("destination", dest.Name()),
+			logger.String("key", key))
+		removeStaging(dest, staging)
+		result.Err = err
+		return result
+	}
+	removeStaging(dest, staging)
+
+	logger.LogInfo("Upload completed successfully",
+		logger.String("destination", dest.Name()),
+		logger.String("location", obj.Location),
+		logger.Int64("bytes", n),
+		logger.Int("download_retries", retries),
+		logger.String("sha256", sums.SHA256))
+	metrics.ObserveUpload(dest.Name(), n, elapsed)
+	result.Location = obj.Location
+	result.Size = n
+	result.SHA256 = sums.SHA256
+	result.MD5 = sums.MD5
+	return result
+}
+
+// removeStaging 删除暂存对象，失败只记录日志，暂存对象不会被当作已导出的备份；
+// 任务被取消（如服务关闭）时也要清理，不使用任务的 context
+func removeStaging(dest Destination, staging string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	if err := dest.Delete(ctx, staging); err != nil {
+		logger.LogWarn("Failed to delete staged object",
+			logger.Error(err),
+			logger.String("destination", dest.Name()),
+			logger.String("key", staging))
+	}
+}
+
+// verifySize 比较实际传输的字节数与文件大小和备份大小，未知大小（<=0）不参与比较
+func verifySize(n, contentLength, expectedSize int64) error {
+	if contentLength > 0 && n != contentLength {
+		return fmt.Errorf("%w: read %d bytes, Content-Length %d", ErrSizeMismatch, n, contentLength)
+	}
+	if expectedSize > 0 && n != expectedSize {
+		return fmt.Errorf("%w: read %d bytes, backup size %d", ErrSizeMismatch, n, expectedSize)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package destination
+
+import (
+	"backuprds/internal/config"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// testFile 模拟下载中的备份文件，读取 failAfter 字节后返回 readErr
+type testFile struct {
+	r         *bytes.Reader
+	size      int64
+	failAfter int
+	readErr   error
+	read      int
+}
+
+func (f *testFile) Read(p []byte) (int, error) {
+	if f.readErr != nil && f.read >= f.failAfter {
+		return 0, f.readErr
+	}
+	if f.readErr != nil && len(p) > f.failAfter-f.read {
+		p = p[:f.failAfter-f.read]
+	}
+	n, err := f.r.Read(p)
+	f.read += n
+	return n, err
+}
+
+func (f *testFile) Close() error { return nil }
+func (f *testFile) Size() int64  { return f.size }
+func (f *testFile) Retries() int { return 0 }
+
+func TestVerifySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		n             int64
+		contentLength int64
+		expectedSize  int64
+		wantErr       bool
+	}{
+		{"sizes match", 100, 100, 100, false},
+		{"unknown sizes", 100, -1, 0, false},
+		{"content length only", 100, 100, 0, false},
+		{"short read", 90, 100, 100, true},
+		{"backup size differs", 100, 100, 120, true},
+		{"backup size differs without content length", 100, -1, 120, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySize(tt.n, tt.contentLength, tt.expectedSize)
+			if tt.wantErr != errors.Is(err, ErrSizeMismatch) {
+				t.Errorf("verifySize(%d, %d, %d) = %v, wantErr %v", tt.n, tt.contentLength, tt.expectedSize, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadLocal(t *testing.T) {
+	data := bytes.Repeat([]byte("backup-data-"), 200000)
+	sha := sha256.Sum256(data)
+	sum := md5.Sum(data)
+	wantSHA256, wantMD5 := hex.EncodeToString(sha[:]), hex.EncodeToString(sum[:])
+	readErr := errors.New("connection lost")
+
+	tests := []struct {
+		name         string
+		size         int64
+		expectedSize int64
+		failAfter    int
+		readErr      error
+		wantErr      func(error) bool
+	}{
+		{name: "complete upload", size: int64(len(data)), expectedSize: int64(len(data))},
+		{name: "unknown content length", size: -1},
+		{
+			name:    "content length mismatch",
+			size:    int64(len(data)) + 1,
+			wantErr: func(err error) bool { return errors.Is(err, ErrSizeMismatch) },
+		},
+		{
+			name:         "backup size mismatch",
+			size:         int64(len(data)),
+			expectedSize: int64(len(data)) - 1,
+			wantErr:      func(err error) bool { return errors.Is(err, ErrSizeMismatch) },
+		},
+		{
+			name:      "download fails midway",
+			size:      int64(len(data)),
+			failAfter: len(data) / 2,
+			readErr:   readErr,
+			wantErr: func(err error) bool {
+				var downloadErr *DownloadError
+				return errors.As(err, &downloadErr) && errors.Is(err, readErr)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := []string{t.TempDir(), t.TempDir()}
+			dests := []Destination{
+				newLocal("a", config.DestinationConfig{Path: roots[0]}),
+				newLocal("b", config.DestinationConfig{Path: roots[1]}),
+			}
+			file := &testFile{r: bytes.NewReader(data), size: tt.size, failAfter: tt.failAfter, readErr: tt.readErr}
+
+			results := Upload(context.Background(), dests, file, "prod/backup.xb", tt.expectedSize)
+			if len(results) != len(dests) {
+				t.Fatalf("got %d results, want %d", len(results), len(dests))
+			}
+			for i, result := range results {
+				if result.Destination != dests[i].Name() {
+					t.Errorf("result %d destination = %s, want %s", i, result.Destination, dests[i].Name())
+				}
+
+				if tt.wantErr != nil {
+					if !tt.wantErr(result.Err) {
+						t.Errorf("destination %s error = %v", result.Destination, result.Err)
+					}
+					// 失败时不能留下暂存文件或不完整的备份
+					if files := listFiles(t, roots[i]); len(files) != 0 {
+						t.Errorf("destination %s left files %q", result.Destination, files)
+					}
+					continue
+				}
+
+				if result.Err != nil {
+					t.Fatalf("destination %s error = %v", result.Destination, result.Err)
+				}
+				if result.Size != int64(len(data)) || result.SHA256 != wantSHA256 || result.MD5 != wantMD5 {
+					t.Errorf("destination %s result = %+v", result.Destination, result)
+				}
+				wantFiles := []string{"prod/backup.xb", "prod/backup.xb.md5", "prod/backup.xb.sha256"}
+				if files := listFiles(t, roots[i]); !slices.Equal(files, wantFiles) {
+					t.Errorf("destination %s files = %q, want %q", result.Destination, files, wantFiles)
+				}
+				checkFile(t, filepath.Join(roots[i], "prod/backup.xb"), string(data))
+				checkFile(t, filepath.Join(roots[i], "prod/backup.xb.sha256"), wantSHA256+"  backup.xb\n")
+				checkFile(t, filepath.Join(roots[i], "prod/backup.xb.md5"), wantMD5+"  backup.xb\n")
+
+				obj, err := dests[i].Stat(context.Background(), "prod/backup.xb")
+				if err != nil || obj.SHA256 != wantSHA256 || obj.Size != int64(len(data)) {
+					t.Errorf("Stat() = %+v, %v", obj, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadOneDestinationFails(t *testing.T) {
+	data := []byte("backup-data")
+	good := t.TempDir()
+	// 目录路径被普通文件占用，无法创建文件
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dests := []Destination{
+		newLocal("blocked", config.DestinationConfig{Path: blocked}),
+		newLocal("good", config.DestinationConfig{Path: good}),
+	}
+	file := &testFile{r: bytes.NewReader(data), size: int64(len(data))}
+
+	results := Upload(context.Background(), dests, file, "backup.xb", int64(len(data)))
+	if results[0].Err == nil {
+		t.Errorf("blocked destination succeeded")
+	}
+	if results[1].Err != nil {
+		t.Fatalf("good destination error = %v", results[1].Err)
+	}
+	checkFile(t, filepath.Join(good, "backup.xb"), string(data))
+}
+
+// listFiles 返回 root 下所有文件相对 root 的路径，已排序
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func checkFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s has %d bytes, want %d", path, len(got), len(want))
+	}
+}
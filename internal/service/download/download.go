@@ -0,0 +1,346 @@
+// Package download 提供基于 HTTP Range 请求、可断点续传的备份文件下载
+package download
+
+import (
+	"backuprds/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 10
+	defaultRetryDelay = 5 * time.Second
+)
+
+var (
+	// ErrRangeNotSupported 服务端不支持 Range 请求，无法从中断处续传
+	ErrRangeNotSupported = errors.New("server does not support range requests")
+	// ErrRetriesExhausted 重试次数已用完
+	ErrRetriesExhausted = errors.New("download retries exhausted")
+)
+
+// Options 下载参数
+type Options struct {
+	// MaxRetries 整个下载过程允许的重试次数，<=0 时使用默认值
+	MaxRetries int
+	// RetryDelay 首次重试前的等待时间，之后按重试次数线性增加
+	RetryDelay time.Duration
+	// RefreshURL 签名下载链接过期（403）时重新获取链接，为空时不刷新
+	RefreshURL func() (string, error)
+	// Connections 并发下载的连接数，<=1 时单连接顺序下载
+	Connections int
+	// ChunkSize 并发下载时每个分块的大小
+	ChunkSize int64
+	// MaxMemory 并发下载时缓存在内存中的分块总大小上限
+	MaxMemory int64
+}
+
+// File 下载中的远端文件，按顺序读取
+type File interface {
+	io.ReadCloser
+	// Size 文件总大小，未知时返回 -1
+	Size() int64
+	// Retries 已使用的重试次数
+	Retries() int
+}
+
+// StatusError 下载请求返回了非预期的 HTTP 状态码
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.Code)
+}
+
+// Open 建立下载连接，连接失败时按重试次数重试，ctx 取消时中断下载和重试等待。
+// 配置了多个连接且服务端支持 Range 请求时分块并发下载，否则单连接顺序下载
+func Open(ctx context.Context, url string, opts Options) (File, error) {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+
+	s := newSession(ctx, url, opts)
+	if opts.Connections > 1 {
+		f, err := openParallel(s)
+		if f != nil || err != nil {
+			return f, err
+		}
+	}
+
+	r := newReader(s, 0, -1)
+	if err := r.resume(); err != nil {
+		s.cancel()
+		return nil, err
+	}
+	return r, nil
+}
+
+// session 同一文件的各个连接共享的下载链接和重试次数
+type session struct {
+	opts   Options
+	client *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	url     string
+	retries int
+}
+
+func newSession(parent context.Context, url string, opts Options) *session {
+	ctx, cancel := context.WithCancel(parent)
+	return &session{
+		opts:   opts,
+		client: http.DefaultClient,
+		ctx:    ctx,
+		cancel: cancel,
+		url:    url,
+	}
+}
+
+func (s *session) currentURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.url
+}
+
+// refresh 重新获取下载链接，stale 已被其他连接刷新时直接返回
+func (s *session) refresh(stale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.url != stale {
+		return nil
+	}
+	url, err := s.opts.RefreshURL()
+	if err != nil {
+		return fmt.Errorf("failed to refresh download URL: %v", err)
+	}
+	s.url = url
+	logger.LogInfo("Download URL refreshed")
+	return nil
+}
+
+// backoff 消耗一次重试并等待，重试次数用完或下载被取消时返回错误
+func (s *session) backoff(cause error, offset int64) error {
+	s.mu.Lock()
+	if s.retries >= s.opts.MaxRetries {
+		retries := s.retries
+		s.mu.Unlock()
+		return fmt.Errorf("%w after %d retries at offset %d: %v", ErrRetriesExhausted, retries, offset, cause)
+	}
+	s.retries++
+	retries := s.retries
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(s.opts.RetryDelay * time.Duration(retries)):
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *session) retryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retries
+}
+
+// Reader 顺序读取远端文件或其中的一段，传输中断时从已接收的位置续传
+type Reader struct {
+	s      *session
+	body   io.ReadCloser
+	offset int64
+	// end 读取范围的结束位置（不含），-1 表示读到文件末尾
+	end  int64
+	size int64
+}
+
+func newReader(s *session, start, end int64) *Reader {
+	return &Reader{s: s, offset: start, end: end, size: -1}
+}
+
+// Size 文件总大小，未知时返回 -1
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// Retries 已使用的重试次数
+func (r *Reader) Retries() int {
+	return r.s.retryCount()
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if err := r.resume(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil {
+			return n, nil
+		}
+		target := r.end
+		if target < 0 {
+			target = r.size
+		}
+		if err == io.EOF && (target < 0 || r.offset >= target) {
+			return n, io.EOF
+		}
+
+		// 连接在文件结束前断开
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.body.Close()
+		r.body = nil
+
+		if retryErr := r.s.backoff(err, r.offset); retryErr != nil {
+			return n, retryErr
+		}
+		logger.LogWarn("Download interrupted, resuming",
+			logger.Error(err),
+			logger.Int64("offset", r.offset),
+			logger.Int64("size", r.size),
+			logger.Int("retry", r.Retries()))
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close 关闭当前连接
+func (r *Reader) Close() error {
+	r.s.cancel()
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// resume 从当前位置建立连接，可重试的错误消耗重试次数
+func (r *Reader) resume() error {
+	for {
+		url := r.s.currentURL()
+		err := r.connect(url)
+		if err == nil {
+			return nil
+		}
+		if r.s.ctx.Err() != nil {
+			return r.s.ctx.Err()
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusForbidden && r.s.opts.RefreshURL != nil {
+			// 签名链接过期，重新获取下载链接
+			if refreshErr := r.s.refresh(url); refreshErr != nil {
+				return refreshErr
+			}
+		} else if !retryable(err) {
+			return err
+		}
+
+		if retryErr := r.s.backoff(err, r.offset); retryErr != nil {
+			return retryErr
+		}
+		logger.LogWarn("Download request failed, retrying",
+			logger.Error(err),
+			logger.Int64("offset", r.offset),
+			logger.Int("retry", r.Retries()))
+	}
+}
+
+// connect 发起 GET 请求，从文件中间开始或只读取一段时使用 Range 请求
+func (r *Reader) connect(url string) error {
+	req, err := http.NewRequestWithContext(r.s.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid download URL: %v", err)
+	}
+	ranged := r.offset > 0 || r.end >= 0
+	if r.end >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", r.offset, r.end-1))
+	} else if r.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	}
+
+	resp, err := r.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if ranged {
+			resp.Body.Close()
+			return ErrRangeNotSupported
+		}
+		r.size = resp.ContentLength
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != r.offset {
+			resp.Body.Close()
+			return fmt.Errorf("%w: unexpected Content-Range %q", ErrRangeNotSupported, resp.Header.Get("Content-Range"))
+		}
+		r.size = total
+	default:
+		resp.Body.Close()
+		return &StatusError{Code: resp.StatusCode}
+	}
+
+	r.body = resp.Body
+	return nil
+}
+
+// retryable 网络错误、5xx 和 429 可以重试
+func retryable(err error) bool {
+	if errors.Is(err, ErrRangeNotSupported) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// parseContentRange 解析 "bytes start-end/total"，total 未知时返回 -1
+func parseContentRange(value string) (start, total int64, err error) {
+	value = strings.TrimPrefix(value, "bytes ")
+	rangePart, totalPart, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	startPart, _, ok := strings.Cut(rangePart, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	start, err = strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if totalPart == "*" {
+		return start, -1, nil
+	}
+	total, err = strconv.ParseInt(totalPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, total, nil
+}
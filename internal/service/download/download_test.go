@@ -0,0 +1,225 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{value: "bytes 0-99/100", wantStart: 0, wantTotal: 100},
+		{value: "bytes 100-199/1000", wantStart: 100, wantTotal: 1000},
+		{value: "bytes 5-9/*", wantStart: 5, wantTotal: -1},
+		{value: "bytes */100", wantErr: true},
+		{value: "bytes 0-99", wantErr: true},
+		{value: "bytes x-99/100", wantErr: true},
+		{value: "bytes 0-99/abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, total, err := parseContentRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if start != tt.wantStart || total != tt.wantTotal {
+				t.Errorf("parseContentRange(%q) = %d, %d, want %d, %d", tt.value, start, total, tt.wantStart, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// testServer 记录每次请求的 Range 头，handle 按请求序号（从 0 开始）返回响应
+type testServer struct {
+	mu     sync.Mutex
+	ranges []string
+	handle func(n int, w http.ResponseWriter, r *http.Request)
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	n := len(s.ranges)
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+	s.handle(n, w, r)
+}
+
+func (s *testServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+// serveRange 按 Range 头返回 data，支持续传
+func serveRange(data []byte) func(int, http.ResponseWriter, *http.Request) {
+	return func(_ int, w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+// interruptFirst 第一次请求只发送一半数据后断开连接，之后交给 next
+func interruptFirst(data []byte, next func(int, http.ResponseWriter, *http.Request)) func(int, http.ResponseWriter, *http.Request) {
+	return func(n int, w http.ResponseWriter, r *http.Request) {
+		if n > 0 {
+			next(n, w, r)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data[:len(data)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func TestOpenResumesFromOffset(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	ignoreRange := func(_ int, w http.ResponseWriter, _ *http.Request) {
+		w.Write(data)
+	}
+
+	tests := []struct {
+		name        string
+		handle      func(int, http.ResponseWriter, *http.Request)
+		maxRetries  int
+		refresh     bool
+		wantErr     error
+		wantStatus  int
+		wantRetries int
+		wantRanges  []string
+	}{
+		{
+			name:        "complete download",
+			handle:      serveRange(data),
+			wantRanges:  []string{""},
+			wantRetries: 0,
+		},
+		{
+			name:        "resume after interruption",
+			handle:      interruptFirst(data, serveRange(data)),
+			wantRanges:  []string{"", "bytes=500-"},
+			wantRetries: 1,
+		},
+		{
+			name:       "server ignores range",
+			handle:     interruptFirst(data, ignoreRange),
+			wantErr:    ErrRangeNotSupported,
+			wantRanges: []string{"", "bytes=500-"},
+		},
+		{
+			name: "refresh expired URL",
+			handle: func(n int, w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("signed") != "2" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				serveRange(data)(n, w, r)
+			},
+			refresh:     true,
+			wantRanges:  []string{"", ""},
+			wantRetries: 1,
+		},
+		{
+			name: "not found is not retried",
+			handle: func(_ int, w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantRanges: []string{""},
+		},
+		{
+			name: "server errors exhaust retries",
+			handle: func(_ int, w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			maxRetries: 2,
+			wantErr:    ErrRetriesExhausted,
+			wantRanges: []string{"", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &testServer{handle: tt.handle}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			opts := Options{MaxRetries: tt.maxRetries, RetryDelay: time.Millisecond}
+			if tt.refresh {
+				opts.RefreshURL = func() (string, error) { return ts.URL + "?signed=2", nil }
+			}
+
+			got, err := readAll(ts.URL+"?signed=1", opts)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("download error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantStatus != 0:
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.Code != tt.wantStatus {
+					t.Fatalf("download error = %v, want status %d", err, tt.wantStatus)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("download error = %v", err)
+				}
+				if !bytes.Equal(got.data, data) {
+					t.Errorf("downloaded %d bytes, want %d", len(got.data), len(data))
+				}
+				if got.size != int64(len(data)) {
+					t.Errorf("Size() = %d, want %d", got.size, len(data))
+				}
+				if got.retries != tt.wantRetries {
+					t.Errorf("Retries() = %d, want %d", got.retries, tt.wantRetries)
+				}
+			}
+
+			ranges := srv.requests()
+			if len(ranges) != len(tt.wantRanges) {
+				t.Fatalf("requests = %q, want %q", ranges, tt.wantRanges)
+			}
+			for i := range ranges {
+				if ranges[i] != tt.wantRanges[i] {
+					t.Errorf("request %d Range = %q, want %q", i, ranges[i], tt.wantRanges[i])
+				}
+			}
+		})
+	}
+}
+
+// result 下载的内容、文件大小和重试次数
+type result struct {
+	data    []byte
+	size    int64
+	retries int
+}
+
+func readAll(url string, opts Options) (*result, error) {
+	f, err := Open(context.Background(), url, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return &result{data: data, size: f.Size(), retries: f.Retries()}, nil
+}
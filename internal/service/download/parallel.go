@@ -0,0 +1,196 @@
+package download
+
+import (
+	"backuprds/internal/logger"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	defaultChunkSize = 64 << 20
+	defaultMaxMemory = 1 << 30
+)
+
+var errClosed = errors.New("download closed")
+
+// chunk 并发下载的一个分块
+type chunk struct {
+	start int64
+	end   int64
+	data  []byte
+	err   error
+	done  chan struct{}
+}
+
+// parallelReader 多个连接并发下载分块，按文件顺序输出
+type parallelReader struct {
+	s    *session
+	size int64
+
+	// ordered 按文件顺序排列的已派发分块
+	ordered chan *chunk
+	tasks   chan *chunk
+	// slots 限制缓存在内存中的分块数
+	slots chan struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	// err 导致下载停止的第一个错误
+	err error
+
+	cur *chunk
+	pos int
+}
+
+// openParallel 探测文件大小和 Range 支持，不支持时返回 nil 由调用方改为顺序下载
+func openParallel(s *session) (File, error) {
+	probe := newReader(s, 0, 1)
+	err := probe.resume()
+	if errors.Is(err, ErrRangeNotSupported) {
+		logger.LogInfo("Range requests not supported, downloading with a single connection")
+		return nil, nil
+	}
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+	probe.body.Close()
+	probe.body = nil
+
+	chunkSize := s.opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	if probe.size <= chunkSize {
+		return nil, nil
+	}
+	maxMemory := s.opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	inFlight := int(maxMemory / chunkSize)
+	if inFlight < 1 {
+		inFlight = 1
+	}
+	connections := s.opts.Connections
+	if connections > inFlight {
+		connections = inFlight
+	}
+
+	p := &parallelReader{
+		s:       s,
+		size:    probe.size,
+		ordered: make(chan *chunk, inFlight),
+		tasks:   make(chan *chunk),
+		slots:   make(chan struct{}, inFlight),
+		stop:    make(chan struct{}),
+	}
+	go p.dispatch(chunkSize)
+	for i := 0; i < connections; i++ {
+		go p.work()
+	}
+
+	logger.LogInfo("Starting parallel download",
+		logger.Int64("size", p.size),
+		logger.Int("connections", connections),
+		logger.Int64("chunk_size", chunkSize),
+		logger.Int("chunks_in_memory", inFlight))
+	return p, nil
+}
+
+// dispatch 按顺序派发分块，内存中的分块数达到上限时等待
+func (p *parallelReader) dispatch(chunkSize int64) {
+	defer close(p.ordered)
+	defer close(p.tasks)
+
+	for start := int64(0); start < p.size; start += chunkSize {
+		end := start + chunkSize
+		if end > p.size {
+			end = p.size
+		}
+
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.stop:
+			return
+		}
+		c := &chunk{start: start, end: end, done: make(chan struct{})}
+		p.ordered <- c
+		select {
+		case p.tasks <- c:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// work 下载分块，任一分块失败时停止整个下载
+func (p *parallelReader) work() {
+	for c := range p.tasks {
+		c.data = make([]byte, c.end-c.start)
+		r := newReader(p.s, c.start, c.end)
+		_, c.err = io.ReadFull(r, c.data)
+		if r.body != nil {
+			r.body.Close()
+		}
+		if c.err != nil {
+			p.abort(c.err)
+		}
+		close(c.done)
+	}
+}
+
+func (p *parallelReader) abort(err error) {
+	p.stopOnce.Do(func() {
+		p.err = err
+		close(p.stop)
+		p.s.cancel()
+	})
+}
+
+func (p *parallelReader) Read(b []byte) (int, error) {
+	for p.cur == nil || p.pos >= len(p.cur.data) {
+		if p.cur != nil {
+			// 分块已读完，释放内存配额
+			p.cur = nil
+			<-p.slots
+		}
+
+		c, ok := <-p.ordered
+		if !ok {
+			return 0, io.EOF
+		}
+		select {
+		case <-c.done:
+		case <-p.stop:
+			return 0, p.err
+		}
+		if c.err != nil {
+			// 其他分块失败会取消本分块，返回最先发生的错误
+			return 0, p.err
+		}
+		p.cur = c
+		p.pos = 0
+	}
+
+	n := copy(b, p.cur.data[p.pos:])
+	p.pos += n
+	return n, nil
+}
+
+// Close 停止所有连接
+func (p *parallelReader) Close() error {
+	p.abort(errClosed)
+	return nil
+}
+
+// Size 文件总大小
+func (p *parallelReader) Size() int64 {
+	return p.size
+}
+
+// Retries 所有连接已使用的重试次数
+func (p *parallelReader) Retries() int {
+	return p.s.retryCount()
+}
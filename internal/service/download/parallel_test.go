@@ -0,0 +1,96 @@
+package download
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOpenParallel(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	// interruptChunk 第一次请求该分块时发送一半数据后断开连接
+	var interrupted atomic.Bool
+	interruptChunk := func(n int, w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "bytes=300-399" && interrupted.CompareAndSwap(false, true) {
+			w.Header().Set("Content-Range", "bytes 300-399/1000")
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[300:350])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		serveRange(data)(n, w, r)
+	}
+
+	tests := []struct {
+		name         string
+		handle       func(int, http.ResponseWriter, *http.Request)
+		chunkSize    int64
+		wantRequests int
+		wantRetries  int
+	}{
+		{
+			name:         "chunks over several connections",
+			handle:       serveRange(data),
+			chunkSize:    100,
+			wantRequests: 11,
+		},
+		{
+			name:         "interrupted chunk resumes",
+			handle:       interruptChunk,
+			chunkSize:    100,
+			wantRequests: 12,
+			wantRetries:  1,
+		},
+		{
+			name: "no range support falls back to one connection",
+			handle: func(_ int, w http.ResponseWriter, _ *http.Request) {
+				w.Write(data)
+			},
+			chunkSize:    100,
+			wantRequests: 2,
+		},
+		{
+			name:         "file within one chunk",
+			handle:       serveRange(data),
+			chunkSize:    1000,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &testServer{handle: tt.handle}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			got, err := readAll(ts.URL, Options{
+				RetryDelay:  time.Millisecond,
+				Connections: 4,
+				ChunkSize:   tt.chunkSize,
+				MaxMemory:   300,
+			})
+			if err != nil {
+				t.Fatalf("download error = %v", err)
+			}
+			if !bytes.Equal(got.data, data) {
+				t.Errorf("downloaded data differs from source (%d bytes, want %d)", len(got.data), len(data))
+			}
+			if got.size != int64(len(data)) {
+				t.Errorf("Size() = %d, want %d", got.size, len(data))
+			}
+			if got.retries != tt.wantRetries {
+				t.Errorf("Retries() = %d, want %d", got.retries, tt.wantRetries)
+			}
+			if n := len(srv.requests()); n != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+// Package export 封装各云厂商备份导出流程，供 HTTP 处理器和后台任务共同调用
+package export
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/jobs"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/destination"
+	"backuprds/internal/service/download"
+	"backuprds/internal/service/source"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidEnv 环境未在配置中定义
+	ErrInvalidEnv = errors.New("invalid environment")
+	// ErrS3ConfigMissing 实例未指定存储目标且S3导出配置缺失
+	ErrS3ConfigMissing = errors.New("S3 configuration is missing")
+	// ErrNoBackup 没有可用的备份
+	ErrNoBackup = errors.New("no backup found")
+	// ErrDestinationsFailed 部分存储目标上传失败
+	ErrDestinationsFailed = errors.New("upload to some destinations failed")
+)
+
+// OpError 记录导出流程中失败的步骤
+type OpError struct {
+	Op  string
+	Err error
+}
+
+func (e *OpError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// AliyunOptions 阿里云备份导出参数
+type AliyunOptions struct {
+	// BackupID 指定导出的备份集，为空时导出最新备份
+	BackupID string
+	// Destinations 上传的存储目标，为空时使用实例配置的目标
+	Destinations []string
+	// JobID 触发本次导出的后台任务，写入导出历史
+	JobID string
+	// Force 备份已导出时仍重新上传
+	Force bool
+	// OnUpload 在下载连接建立、开始上传时回调
+	OnUpload func()
+}
+
+// AliyunS3Result 阿里云备份上传的结果，Destination 等字段为第一个成功的存储目标
+type AliyunS3Result struct {
+	Env             string
+	BackupID        string
+	BackupStartTime string
+	Destination     string
+	Bucket          string
+	Region          string
+	S3Key           string
+	Location        string
+	Size            int64
+	SHA256          string
+	MD5             string
+	// AlreadyExported 备份此前已导出到全部目标，本次未重新上传
+	AlreadyExported bool
+	// Destinations 各存储目标的上传结果
+	Destinations []jobs.DestinationResult
+}
+
+// setPrimary 用第一个成功的存储目标填充结果
+func (r *AliyunS3Result) setPrimary() {
+	for _, d := range r.Destinations {
+		if d.Succeeded() {
+			r.Destination = d.Name
+			r.Bucket = d.Bucket
+			r.Region = d.Region
+			r.S3Key = d.Key
+			r.Location = d.Location
+			r.Size = d.Size
+			return
+		}
+	}
+}
+
+// CheckAliyunToS3 校验环境和存储目标配置，不发起任何云端调用，destNames 为空时使用实例配置的存储目标
+func CheckAliyunToS3(cfg *config.Config, env string, destNames []string) (config.InstanceConfig, []destination.Destination, error) {
+	instanceConfig, ok := cfg.RDS.Aliyun.Instances[env]
+	if !ok {
+		return config.InstanceConfig{}, nil, ErrInvalidEnv
+	}
+
+	if len(destNames) == 0 {
+		destNames = destination.NamesFor(instanceConfig)
+	}
+	dests, err := destination.GetAll(cfg, destNames)
+	if errors.Is(err, destination.ErrNotConfigured) {
+		return config.InstanceConfig{}, nil, ErrS3ConfigMissing
+	}
+	if err != nil {
+		return config.InstanceConfig{}, nil, err
+	}
+	return instanceConfig, dests, nil
+}
+
+// AliyunToS3 获取指定环境最新的阿里云RDS备份，下载一次并同时上传到全部存储目标，结果写入导出历史。
+// 有目标失败时仍返回包含各目标上传结果的 result，同时返回错误，多个目标时错误包装 ErrDestinationsFailed；
+// ctx 取消时中止下载和上传
+func AliyunToS3(ctx context.Context, env string, opts AliyunOptions) (result *AliyunS3Result, err error) {
+	cfg := config.GetConfig()
+
+	instanceConfig, dests, err := CheckAliyunToS3(cfg, env, opts.Destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	defer func() {
+		observeAliyunExport(env, start, result, err)
+	}()
+
+	record := newRecord(store.ProviderAliyun, env)
+	if record != nil {
+		record.JobID = opts.JobID
+		record.Bucket = dests[0].Bucket()
+		saveRecord(record)
+		defer func() {
+			if record == nil {
+				return
+			}
+			if result != nil {
+				if result.Bucket != "" {
+					record.Bucket = result.Bucket
+				}
+				record.Key = result.S3Key
+				record.Bytes = result.Size
+				record.SHA256 = result.SHA256
+				record.Destinations = result.Destinations
+			}
+			record.Finish(err)
+			saveRecord(record)
+		}()
+	}
+
+	src, err := source.Get(store.ProviderAliyun)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := resolveBackup(ctx, src, instanceConfig, opts.BackupID)
+	if err != nil {
+		return nil, err
+	}
+	startTime := formatStartTime(backup)
+	if record != nil {
+		record.SourceID = backup.ID
+		record.SourceTime = startTime
+	}
+
+	s3Key, err := aliyunS3Key(ctx, cfg, src, env, instanceConfig, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &AliyunS3Result{
+		Env:             env,
+		BackupID:        backup.ID,
+		BackupStartTime: startTime,
+	}
+
+	pending := dests
+	if !opts.Force {
+		result.Destinations, pending = findExported(ctx, dests, s3Key, backup.Size)
+	}
+	if len(pending) == 0 && destinationsError(result.Destinations) == nil {
+		logger.LogInfo("Aliyun backup already exported, skipping upload",
+			logger.String("env", env),
+			logger.String("backup_id", backup.ID),
+			logger.String("key", s3Key))
+		result.AlreadyExported = true
+		result.setPrimary()
+		if record != nil {
+			record.Key = result.S3Key
+			record.Bytes = result.Size
+			record.Destinations = result.Destinations
+			record.Finish(nil)
+			record.Outcome = store.OutcomeSkipped
+			saveRecord(record)
+			record = nil
+		}
+		return result, nil
+	}
+	if len(pending) == 0 {
+		result.setPrimary()
+		return result, destinationsError(result.Destinations)
+	}
+
+	logger.LogInfo("Exporting aliyun backup",
+		logger.String("env", env),
+		logger.String("backup_id", backup.ID),
+		logger.String("backup_start_time", startTime),
+		logger.String("destinations", strings.Join(destinationNames(pending), ",")))
+
+	file, err := src.OpenBackup(ctx, instanceConfig, backup, downloadOptions(cfg))
+	if err != nil {
+		logger.LogError("Failed to download backup",
+			logger.Error(err),
+			logger.String("env", env),
+			logger.String("backup_id", backup.ID))
+		return nil, &OpError{Op: "failed to download backup", Err: err}
+	}
+	defer file.Close()
+
+	if opts.OnUpload != nil {
+		opts.OnUpload()
+	}
+
+	var downloadErr *destination.DownloadError
+	for _, uploaded := range destination.Upload(ctx, pending, file, s3Key, backup.Size) {
+		d := jobs.DestinationResult{
+			Name:     uploaded.Destination,
+			Bucket:   uploaded.Bucket,
+			Region:   uploaded.Region,
+			Key:      uploaded.Key,
+			Location: uploaded.Location,
+			Size:     uploaded.Size,
+		}
+		if uploaded.Err != nil {
+			errors.As(uploaded.Err, &downloadErr)
+			d.Error = uploaded.Err.Error()
+		} else {
+			result.SHA256 = uploaded.SHA256
+			result.MD5 = uploaded.MD5
+		}
+		result.Destinations = append(result.Destinations, d)
+	}
+	result.setPrimary()
+	// 下载中断时所有目标都失败，错误来自下载而不是存储目标
+	if downloadErr != nil {
+		logger.LogError("Failed to download backup",
+			logger.Error(downloadErr),
+			logger.String("env", env),
+			logger.String("backup_id", backup.ID))
+		return result, &OpError{Op: "failed to download backup", Err: downloadErr.Err}
+	}
+	return result, destinationsError(result.Destinations)
+}
+
+// destinationsError 汇总失败的存储目标，全部成功时返回 nil
+func destinationsError(results []jobs.DestinationResult) error {
+	var failed []string
+	for _, d := range results {
+		if !d.Succeeded() {
+			failed = append(failed, d.Name+": "+d.Error)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		return &OpError{Op: "failed to upload to S3", Err: errors.New(results[0].Error)}
+	}
+	return &OpError{
+		Op:  "failed to upload to S3",
+		Err: fmt.Errorf("%w: %s", ErrDestinationsFailed, strings.Join(failed, "; ")),
+	}
+}
+
+// resolveBackup 获取指定的备份集，未指定时获取最新备份，备份必须有公网下载链接
+func resolveBackup(ctx context.Context, src source.BackupSource, instanceConfig config.InstanceConfig, backupID string) (*source.Backup, error) {
+	var backup *source.Backup
+	var err error
+
+	if backupID != "" {
+		backup, err = src.GetBackup(ctx, instanceConfig, backupID)
+		if errors.Is(err, source.ErrBackupNotFound) {
+			return nil, ErrNoBackup
+		}
+	} else {
+		backup, err = src.LatestBackup(ctx, instanceConfig)
+	}
+	if err != nil {
+		return nil, &OpError{Op: "failed to get backup URLs", Err: err}
+	}
+
+	if backup == nil || backup.DownloadURL == "" {
+		return nil, ErrNoBackup
+	}
+	return backup, nil
+}
+
+// formatStartTime 备份开始时间，格式与阿里云接口返回的一致
+func formatStartTime(backup *source.Backup) string {
+	return formatSourceTime(backup.StartTime)
+}
+
+// formatSourceTime 备份或快照时间统一使用 RFC3339，未知时为空
+func formatSourceTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// downloadOptions 根据配置生成下载参数
+func downloadOptions(cfg *config.Config) download.Options {
+	return download.Options{
+		MaxRetries:  cfg.Download.MaxRetries,
+		RetryDelay:  cfg.Download.RetryDelay,
+		Connections: cfg.Download.Connections,
+		ChunkSize:   cfg.Download.ChunkSizeMB << 20,
+		MaxMemory:   cfg.Download.MaxMemoryMB << 20,
+	}
+}
+
+// aliyunS3Key 按路径模板生成备份的 S3 路径，模板只引用备份和实例信息时同一备份多次导出得到相同的路径
+func aliyunS3Key(ctx context.Context, cfg *config.Config, src source.BackupSource, env string, instanceConfig config.InstanceConfig, backup *source.Backup) (string, error) {
+	tmpl := aliyunKeyTemplate(cfg, instanceConfig)
+
+	vars := KeyVars{
+		Env:             env,
+		InstanceID:      instanceConfig.ID,
+		Region:          instanceConfig.Region,
+		BackupID:        backup.ID,
+		BackupStartTime: backup.StartTime,
+	}
+	if usesEngine(tmpl) {
+		instance, err := src.DescribeInstance(ctx, instanceConfig)
+		if err != nil {
+			return "", &OpError{Op: "failed to describe instance", Err: err}
+		}
+		vars.Engine = instance.Engine
+	}
+
+	s3Key, err := RenderKey(tmpl, vars)
+	if err != nil {
+		return "", err
+	}
+	return s3Key, nil
+}
+
+// findExported 检查备份在各存储目标中是否已上传，返回已上传的目标和仍需上传的目标。
+// 对象有校验和且大小与备份集一致时才视为已上传，否则（不完整或早期未校验的对象）重新上传；
+// 检查失败的目标记为失败，不再上传
+func findExported(ctx context.Context, dests []destination.Destination, s3Key string, size int64) (done []jobs.DestinationResult, pending []destination.Destination) {
+	for _, dest := range dests {
+		d := jobs.DestinationResult{
+			Name:   dest.Name(),
+			Bucket: dest.Bucket(),
+			Region: dest.Region(),
+			Key:    s3Key,
+		}
+
+		existing, err := dest.Stat(ctx, s3Key)
+		if errors.Is(err, destination.ErrNotFound) {
+			pending = append(pending, dest)
+			continue
+		}
+		if err == nil && !verified(existing, size) {
+			logger.LogWarn("Existing export is not verified, uploading again",
+				logger.String("destination", dest.Name()),
+				logger.String("key", s3Key),
+				logger.Int64("size", existing.Size),
+				logger.Int64("backup_size", size),
+				logger.Bool("has_checksum", existing.SHA256 != ""))
+			pending = append(pending, dest)
+			continue
+		}
+		if err != nil {
+			logger.LogError("Failed to check existing export",
+				logger.String("destination", dest.Name()),
+				logger.String("key", s3Key),
+				logger.Error(err))
+			d.Error = (&OpError{Op: "failed to check existing export", Err: err}).Error()
+		} else {
+			d.Location = existing.Location
+			d.Size = existing.Size
+			d.AlreadyExported = true
+		}
+		done = append(done, d)
+	}
+	return done, pending
+}
+
+// verified 对象是否为完整上传的备份：有校验和，且大小与备份集声明的大小一致
+func verified(obj *destination.Object, size int64) bool {
+	return obj.SHA256 != "" && size > 0 && obj.Size == size
+}
+
+// destinationNames 存储目标名称列表
+func destinationNames(dests []destination.Destination) []string {
+	names := make([]string, len(dests))
+	for i, dest := range dests {
+		names[i] = dest.Name()
+	}
+	return names
+}
+
+// FindAliyunExport 检查指定环境的备份集（为空时为最新备份）是否已上传到全部存储目标，未全部上传时返回 nil
+func FindAliyunExport(ctx context.Context, env, backupID string, destNames []string) (*AliyunS3Result, error) {
+	cfg := config.GetConfig()
+
+	instanceConfig, dests, err := CheckAliyunToS3(cfg, env, destNames)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := source.Get(store.ProviderAliyun)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := resolveBackup(ctx, src, instanceConfig, backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	s3Key, err := aliyunS3Key(ctx, cfg, src, env, instanceConfig, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	done, pending := findExported(ctx, dests, s3Key, backup.Size)
+	if len(pending) > 0 {
+		return nil, nil
+	}
+	if err := destinationsError(done); err != nil {
+		return nil, err
+	}
+
+	result := &AliyunS3Result{
+		Env:             env,
+		BackupID:        backup.ID,
+		BackupStartTime: formatStartTime(backup),
+		AlreadyExported: true,
+		Destinations:    done,
+	}
+	result.setPrimary()
+	return result, nil
+}
+
+// SubmitAliyunToS3 将阿里云备份上传任务放入后台队列，立即返回任务记录，opts.BackupID 为空时导出最新备份，
+// opts.Force 为 true 时即使备份已导出也重新上传；JobID 和 OnUpload 由任务设置
+func SubmitAliyunToS3(env string, opts AliyunOptions) (*jobs.Job, error) {
+	cfg := config.GetConfig()
+	_, dests, err := CheckAliyunToS3(cfg, env, opts.Destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs.GetManager().Submit(jobs.TypeAliyunExportS3, env,
+		func(j *jobs.Job) {
+			j.BackupID = opts.BackupID
+			j.Destination = dests[0].Name()
+			j.S3Bucket = dests[0].Bucket()
+			j.S3Region = dests[0].Region()
+			for _, dest := range dests {
+				j.Destinations = append(j.Destinations, jobs.DestinationResult{
+					Name:   dest.Name(),
+					Bucket: dest.Bucket(),
+					Region: dest.Region(),
+				})
+			}
+		},
+		func(t *jobs.Task) error {
+			taskOpts := opts
+			taskOpts.JobID = t.ID()
+			taskOpts.OnUpload = func() {
+				t.SetState(jobs.StateUploading)
+			}
+
+			// 后台任务在请求返回后继续执行，不使用请求的 context，服务关闭时由任务管理器取消；
+			// 部分存储目标失败时仍记录各目标的结果，任务标记为失败
+			result, err := AliyunToS3(t.Context(), env, taskOpts)
+			if result != nil {
+				t.Update(func(j *jobs.Job) {
+					j.BackupID = result.BackupID
+					j.BackupStartTime = result.BackupStartTime
+					j.Destination = result.Destination
+					j.S3Bucket = result.Bucket
+					j.S3Region = result.Region
+					j.S3Key = result.S3Key
+					j.Location = result.Location
+					j.Size = result.Size
+					j.SHA256 = result.SHA256
+					j.MD5 = result.MD5
+					j.AlreadyExported = result.AlreadyExported
+					j.Destinations = result.Destinations
+				})
+			}
+			return err
+		})
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrNoSnapshot 没有可用的快照
+	ErrNoSnapshot = errors.New("no snapshots found")
+	// ErrSnapshotNotAvailable 指定的快照不是 available 状态，无法导出
+	ErrSnapshotNotAvailable = errors.New("snapshot is not available for export")
+)
+
+// AwsExportResult AWS RDS快照导出任务启动结果
+type AwsExportResult struct {
+	Env          string
+	ExportTaskID string
+	SnapshotArn  string
+	SnapshotID   string
+	InstanceID   string
+	Region       string
+	KmsKeyId     string
+	S3BucketName string
+	S3Prefix     string
+}
+
+// AwsSnapshot 为指定环境的AWS RDS快照启动导出任务，snapshotID 为空时导出最新的自动快照，结果写入导出历史
+func AwsSnapshot(ctx context.Context, env, snapshotID string) (result *AwsExportResult, err error) {
+	cfg := config.GetConfig()
+
+	instanceConfig, ok := cfg.RDS.Aws.Instances[env]
+	if !ok {
+		return nil, ErrInvalidEnv
+	}
+
+	record := newRecord(store.ProviderAws, env)
+	if record != nil {
+		record.Bucket = instanceConfig.S3BucketName
+		saveRecord(record)
+		defer func() {
+			if err != nil {
+				record.Finish(err)
+			} else {
+				// 导出任务在AWS侧异步执行，保持 started 状态直到任务结束
+				record.ExportTaskID = result.ExportTaskID
+				record.Key = path.Join(result.S3Prefix, result.ExportTaskID)
+			}
+			saveRecord(record)
+		}()
+	}
+
+	logger.LogInfo("Starting export task",
+		logger.String("env", env),
+		logger.String("instance_id", instanceConfig.ID),
+		logger.String("region", instanceConfig.Region))
+
+	snapshot, err := resolveSnapshot(ctx, instanceConfig, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		record.SourceID = snapshot.SnapshotID
+		if snapshot.CreateTime != nil {
+			record.SourceTime = formatSourceTime(*snapshot.CreateTime)
+		}
+	}
+
+	s3Prefix, err := awsS3Prefix(cfg, env, instanceConfig, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	// 启动快照导出任务
+	exportTaskID, err := aws.StartRDSSnapshotExport(
+		ctx,
+		instanceConfig.ID,
+		snapshot.SnapshotArn,
+		aws.TargetFor(instanceConfig),
+		exportRoleArn(cfg, instanceConfig),
+		instanceConfig.KmsKeyId,
+		instanceConfig.S3BucketName,
+		s3Prefix,
+	)
+	if err != nil {
+		return nil, &OpError{Op: "failed to start export task", Err: err}
+	}
+
+	return &AwsExportResult{
+		Env:          env,
+		ExportTaskID: exportTaskID,
+		SnapshotArn:  snapshot.SnapshotArn,
+		SnapshotID:   snapshot.SnapshotID,
+		InstanceID:   instanceConfig.ID,
+		Region:       instanceConfig.Region,
+		KmsKeyId:     instanceConfig.KmsKeyId,
+		S3BucketName: instanceConfig.S3BucketName,
+		S3Prefix:     s3Prefix,
+	}, nil
+}
+
+// exportRoleArn 导出任务写入S3使用的IAM角色，实例配置优先于 exporttask.iamRoleArn，
+// 跨账号访问实例时角色需位于实例所在账号
+func exportRoleArn(cfg *config.Config, instanceConfig config.InstanceConfig) string {
+	if instanceConfig.IamRoleArn != "" {
+		return instanceConfig.IamRoleArn
+	}
+	return cfg.RDS.Aws.ExportTask.IamRoleArn
+}
+
+// resolveSnapshot 获取指定的快照，未指定时获取最新的自动快照
+func resolveSnapshot(ctx context.Context, instanceConfig config.InstanceConfig, snapshotID string) (*aws.Snapshot, error) {
+	if snapshotID == "" {
+		// 先获取最新的快照信息
+		snapshot, err := aws.GetLatestSnapshot(ctx, instanceConfig.ID, aws.TargetFor(instanceConfig))
+		if err != nil {
+			return nil, &OpError{Op: "failed to get snapshot info", Err: err}
+		}
+		// 检查是否找到快照
+		if snapshot == nil || snapshot.SnapshotArn == "" {
+			return nil, ErrNoSnapshot
+		}
+		return snapshot, nil
+	}
+
+	snapshot, err := aws.GetSnapshot(ctx, instanceConfig.ID, aws.TargetFor(instanceConfig), snapshotID)
+	if errors.Is(err, aws.ErrSnapshotNotFound) {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, &OpError{Op: "failed to get snapshot info", Err: err}
+	}
+	if snapshot.Status != aws.SnapshotStatusAvailable {
+		return nil, ErrSnapshotNotAvailable
+	}
+	return snapshot, nil
+}
+
+// awsS3Prefix 按路径模板生成快照导出的S3前缀
+func awsS3Prefix(cfg *config.Config, env string, instanceConfig config.InstanceConfig, snapshot *aws.Snapshot) (string, error) {
+	vars := KeyVars{
+		Env:        env,
+		InstanceID: aws.InstanceName(instanceConfig.ID),
+		Region:     instanceConfig.Region,
+		BackupID:   strings.TrimPrefix(snapshot.SnapshotID, "rds:"),
+		Engine:     snapshot.Engine,
+	}
+	if snapshot.CreateTime != nil {
+		vars.BackupStartTime = *snapshot.CreateTime
+	}
+	return RenderKey(awsKeyTemplate(cfg, instanceConfig), vars)
+}
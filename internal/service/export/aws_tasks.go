@@ -0,0 +1,318 @@
+package export
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWatchInterval = 5 * time.Minute
+	bytesPerGB           = 1 << 30
+)
+
+// AwsExportTasks 查询指定环境的快照导出任务，包括本服务启动的任务和该实例快照的任务
+func AwsExportTasks(ctx context.Context, env string) ([]aws.ExportTask, error) {
+	cfg := config.GetConfig()
+
+	instanceConfig, ok := cfg.RDS.Aws.Instances[env]
+	if !ok {
+		return nil, ErrInvalidEnv
+	}
+
+	tasks, err := aws.DescribeExportTasks(ctx, aws.TargetFor(instanceConfig), aws.ExportTaskFilter{
+		S3Bucket: instanceConfig.S3BucketName,
+	})
+	if err != nil {
+		return nil, &OpError{Op: "failed to describe export tasks", Err: err}
+	}
+
+	started := make(map[string]bool)
+	if s := store.GetStore(); s != nil {
+		records, err := s.ListExports(store.HistoryFilter{Env: env, Provider: store.ProviderAws})
+		if err != nil {
+			logger.LogError("Failed to load AWS export history",
+				logger.String("env", env),
+				logger.Error(err))
+		}
+		for _, r := range records {
+			if r.ExportTaskID != "" {
+				started[r.ExportTaskID] = true
+			}
+		}
+	}
+
+	// 先按导出历史和自动快照标识符匹配，只对剩下的快照查询来源实例
+	target := aws.TargetFor(instanceConfig)
+	instanceName := aws.InstanceName(instanceConfig.ID)
+	result := make([]aws.ExportTask, 0, len(tasks))
+	for _, t := range tasks {
+		if started[t.ExportTaskID] || aws.SnapshotBelongsTo(t.SourceArn, instanceConfig.ID) {
+			result = append(result, t)
+			continue
+		}
+		source, err := snapshotSource(ctx, target, t.SourceArn)
+		if err != nil {
+			return nil, &OpError{Op: "failed to describe snapshot", Err: err}
+		}
+		if source == instanceName {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// FindAwsExportTask 根据任务ID查询导出任务，优先使用导出历史中记录的环境，否则依次查询配置中的各个 region
+func FindAwsExportTask(ctx context.Context, exportTaskID string) (*aws.ExportTask, string, error) {
+	cfg := config.GetConfig()
+
+	if env := exportTaskEnv(exportTaskID); env != "" {
+		if instanceConfig, ok := cfg.RDS.Aws.Instances[env]; ok {
+			task, err := aws.GetExportTask(ctx, aws.TargetFor(instanceConfig), exportTaskID)
+			if err == nil {
+				return task, env, nil
+			}
+			if !errors.Is(err, aws.ErrExportTaskNotFound) {
+				return nil, env, &OpError{Op: "failed to describe export task", Err: err}
+			}
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, instanceConfig := range cfg.RDS.Aws.Instances {
+		if checked[instanceConfig.Region] {
+			continue
+		}
+		checked[instanceConfig.Region] = true
+
+		task, err := aws.GetExportTask(ctx, aws.TargetFor(instanceConfig), exportTaskID)
+		if errors.Is(err, aws.ErrExportTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", &OpError{Op: "failed to describe export task", Err: err}
+		}
+		return task, ownerEnv(ctx, cfg, aws.TargetFor(instanceConfig), task), nil
+	}
+	return nil, "", aws.ErrExportTaskNotFound
+}
+
+// exportTaskEnv 从导出历史中查找启动该任务的环境
+func exportTaskEnv(exportTaskID string) string {
+	s := store.GetStore()
+	if s == nil {
+		return ""
+	}
+
+	records, err := s.ListExports(store.HistoryFilter{
+		Provider:     store.ProviderAws,
+		ExportTaskID: exportTaskID,
+		Limit:        1,
+	})
+	if err != nil {
+		logger.LogError("Failed to load AWS export history",
+			logger.String("export_task_id", exportTaskID),
+			logger.Error(err))
+		return ""
+	}
+	if len(records) == 0 {
+		return ""
+	}
+	return records[0].Env
+}
+
+// ownerEnv 根据快照的来源实例确定任务所属环境，快照已删除时按自动快照的标识符匹配，无法确定时返回空；
+// 多个环境匹配时按环境名排序取第一个，结果与配置的遍历顺序无关
+func ownerEnv(ctx context.Context, cfg *config.Config, target aws.Target, task *aws.ExportTask) string {
+	envs := make([]string, 0, len(cfg.RDS.Aws.Instances))
+	for env, instanceConfig := range cfg.RDS.Aws.Instances {
+		if instanceConfig.Region == target.Region {
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		return ""
+	}
+	sort.Strings(envs)
+
+	source, err := snapshotSource(ctx, target, task.SourceArn)
+	switch {
+	case err != nil:
+		logger.LogWarn("Failed to describe export task source snapshot",
+			logger.String("export_task_id", task.ExportTaskID),
+			logger.String("source_arn", task.SourceArn),
+			logger.Error(err))
+	case source != "":
+		for _, env := range envs {
+			if aws.InstanceName(cfg.RDS.Aws.Instances[env].ID) == source {
+				return env
+			}
+		}
+		return ""
+	}
+
+	for _, env := range envs {
+		if aws.SnapshotBelongsTo(task.SourceArn, cfg.RDS.Aws.Instances[env].ID) {
+			return env
+		}
+	}
+	return ""
+}
+
+// snapshotSources 快照 ARN 到来源实例名的缓存，快照的来源不会改变，已删除的快照记为空字符串
+var snapshotSources sync.Map
+
+// snapshotSource 查询快照的来源实例名，快照已删除或不是实例快照时返回空字符串
+func snapshotSource(ctx context.Context, target aws.Target, snapshotArn string) (string, error) {
+	if source, ok := snapshotSources.Load(snapshotArn); ok {
+		return source.(string), nil
+	}
+	if !strings.Contains(snapshotArn, ":snapshot:") {
+		// 集群快照等其他来源不属于配置中的实例
+		snapshotSources.Store(snapshotArn, "")
+		return "", nil
+	}
+
+	source := ""
+	snapshot, err := aws.DescribeSnapshot(ctx, target, snapshotArn)
+	switch {
+	case err == nil:
+		source = snapshot.InstanceID
+	case !errors.Is(err, aws.ErrSnapshotNotFound):
+		return "", err
+	}
+	snapshotSources.Store(snapshotArn, source)
+	return source, nil
+}
+
+// StartAwsTaskWatcher 定期检查本服务启动的导出任务，任务结束时更新导出历史并记录日志
+func StartAwsTaskWatcher(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			checkAwsTasks(context.Background())
+		}
+	}()
+
+	logger.LogInfo("AWS export task watcher started",
+		logger.Duration("interval", interval))
+}
+
+// checkAwsTasks 检查所有未结束的AWS导出记录
+func checkAwsTasks(ctx context.Context) {
+	s := store.GetStore()
+	if s == nil {
+		return
+	}
+
+	records, err := s.ListExports(store.HistoryFilter{
+		Provider: store.ProviderAws,
+		Outcome:  store.OutcomeStarted,
+	})
+	if err != nil {
+		logger.LogError("Failed to load running AWS export tasks",
+			logger.Error(err))
+		return
+	}
+
+	cfg := config.GetConfig()
+	for _, record := range records {
+		if record.ExportTaskID == "" {
+			continue
+		}
+		instanceConfig, ok := cfg.RDS.Aws.Instances[record.Env]
+		if !ok {
+			continue
+		}
+
+		task, err := aws.GetExportTask(ctx, aws.TargetFor(instanceConfig), record.ExportTaskID)
+		if err != nil {
+			logger.LogWarn("Failed to check AWS export task",
+				logger.String("env", record.Env),
+				logger.String("export_task_id", record.ExportTaskID),
+				logger.Error(err))
+			continue
+		}
+
+		logger.LogDebug("AWS export task progress",
+			logger.String("env", record.Env),
+			logger.String("export_task_id", task.ExportTaskID),
+			logger.String("status", task.Status),
+			logger.Int("percent_progress", int(task.PercentProgress)))
+
+		if !task.Finished() {
+			continue
+		}
+		finishAwsRecord(record, task)
+	}
+}
+
+// finishAwsRecord 根据导出任务结果更新历史记录
+func finishAwsRecord(record *store.ExportRecord, task *aws.ExportTask) {
+	record.Bytes = int64(task.TotalExtractedDataInGB) * bytesPerGB
+
+	switch task.Status {
+	case aws.ExportStatusComplete:
+		record.Finish(nil)
+		logger.LogInfo("AWS export task completed",
+			logger.String("env", record.Env),
+			logger.String("export_task_id", task.ExportTaskID),
+			logger.String("s3_location", task.S3Location()),
+			logger.Int("total_extracted_data_in_gb", int(task.TotalExtractedDataInGB)))
+	default:
+		cause := task.FailureCause
+		if cause == "" {
+			cause = "export task " + task.Status
+		}
+		record.Finish(errors.New(cause))
+		logger.LogError("AWS export task did not complete",
+			logger.String("env", record.Env),
+			logger.String("export_task_id", task.ExportTaskID),
+			logger.String("status", task.Status),
+			logger.String("failure_cause", task.FailureCause))
+	}
+	observeAwsExport(record, task)
+	saveRecord(record)
+}
+
+var (
+	// ErrExportTaskNotOwned 导出任务不是由本服务启动
+	ErrExportTaskNotOwned = errors.New("export task was not started by this service")
+	// ErrExportTaskEnvRemoved 启动导出任务的环境已不在配置中
+	ErrExportTaskEnvRemoved = errors.New("export task env is not configured")
+)
+
+// CancelAwsExportTask 取消本服务启动的导出任务，任务所属环境必须仍在配置中
+func CancelAwsExportTask(ctx context.Context, exportTaskID string) (*aws.ExportTask, string, error) {
+	env := exportTaskEnv(exportTaskID)
+	if env == "" {
+		return nil, "", ErrExportTaskNotOwned
+	}
+
+	instanceConfig, ok := config.GetConfig().RDS.Aws.Instances[env]
+	if !ok {
+		return nil, env, ErrExportTaskEnvRemoved
+	}
+
+	task, err := aws.CancelExportTask(ctx, aws.TargetFor(instanceConfig), exportTaskID)
+	if err != nil {
+		if errors.Is(err, aws.ErrExportTaskNotFound) || errors.Is(err, aws.ErrExportTaskNotCancellable) {
+			return nil, env, err
+		}
+		return nil, env, &OpError{Op: "failed to cancel export task", Err: err}
+	}
+	return task, env, nil
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"backuprds/internal/logger"
+	"backuprds/internal/store"
+	"errors"
+)
+
+// errExportInterrupted 进程退出时阿里云导出尚未结束
+var errExportInterrupted = errors.New("export interrupted by server restart")
+
+// newRecord 创建导出历史记录，存储未初始化时返回 nil
+func newRecord(provider, env string) *store.ExportRecord {
+	if store.GetStore() == nil {
+		return nil
+	}
+
+	record, err := store.NewExportRecord(provider, env)
+	if err != nil {
+		logger.LogError("Failed to create export record",
+			logger.String("env", env),
+			logger.Error(err))
+		return nil
+	}
+	return record
+}
+
+// saveRecord 保存导出历史，失败只记录日志不影响导出本身
+func saveRecord(record *store.ExportRecord) {
+	if err := store.GetStore().SaveExport(record); err != nil {
+		logger.LogError("Failed to save export record",
+			logger.String("record_id", record.ID),
+			logger.String("env", record.Env),
+			logger.Error(err))
+	}
+}
+
+// RecoverInterruptedExports 将上次进程退出时未结束的阿里云导出记录标记为失败，与未结束的后台任务处理方式相同；
+// AWS 导出任务在 AWS 侧继续执行，由任务检查更新结果
+func RecoverInterruptedExports() {
+	s := store.GetStore()
+	if s == nil {
+		return
+	}
+
+	records, err := s.ListExports(store.HistoryFilter{
+		Provider: store.ProviderAliyun,
+		Outcome:  store.OutcomeStarted,
+	})
+	if err != nil {
+		logger.LogError("Failed to load interrupted exports",
+			logger.Error(err))
+		return
+	}
+	for _, record := range records {
+		record.Finish(errExportInterrupted)
+		saveRecord(record)
+
+		logger.LogWarn("Marked interrupted export as failed",
+			logger.String("record_id", record.ID),
+			logger.String("env", record.Env))
+	}
+}
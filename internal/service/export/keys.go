@@ -0,0 +1,159 @@
+package export
+
+import (
+	"backuprds/internal/config"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultAliyunKeyTemplate 阿里云备份上传到S3的默认路径
+const DefaultAliyunKeyTemplate = "${env}/backup-${env}-${backupStartTime}-${backupId}.xb"
+
+// ErrInvalidKeyTemplate 路径模板中包含未知的变量
+var ErrInvalidKeyTemplate = errors.New("invalid key template")
+
+// KeyVars 路径模板中可用的变量
+type KeyVars struct {
+	Env        string
+	InstanceID string
+	Region     string
+	BackupID   string
+	Engine     string
+	// BackupStartTime 备份开始时间，日期变量按 UTC 取值
+	BackupStartTime time.Time
+}
+
+// RenderKey 展开路径模板，支持的变量：
+// ${env} ${instanceId} ${region} ${backupId} ${engine} ${backupStartTime}(20060102-150405)
+// ${YYYY} ${MM} ${DD} ${hh} ${mm}
+func RenderKey(tmpl string, vars KeyVars) (string, error) {
+	t := vars.BackupStartTime.UTC()
+
+	var unknown []string
+	key := os.Expand(tmpl, func(name string) string {
+		switch name {
+		case "env":
+			return vars.Env
+		case "instanceId":
+			return vars.InstanceID
+		case "region":
+			return vars.Region
+		case "backupId":
+			return vars.BackupID
+		case "engine":
+			return vars.Engine
+		case "backupStartTime":
+			return t.Format("20060102-150405")
+		case "YYYY":
+			return t.Format("2006")
+		case "MM":
+			return t.Format("01")
+		case "DD":
+			return t.Format("02")
+		case "hh":
+			return t.Format("15")
+		case "mm":
+			return t.Format("04")
+		}
+		unknown = append(unknown, name)
+		return ""
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("%w %q: unknown placeholder %s", ErrInvalidKeyTemplate, tmpl, strings.Join(unknown, ", "))
+	}
+
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	return key, nil
+}
+
+// ValidateKeyTemplate 检查模板中的变量是否都受支持
+func ValidateKeyTemplate(tmpl string) error {
+	_, err := RenderKey(tmpl, KeyVars{})
+	return err
+}
+
+// ValidateAliyunKeyTemplate 阿里云模板还必须引用 ${backupId} 或 ${backupStartTime}，
+// 否则不同备份得到相同的路径，之后的导出都会被当作已导出而跳过
+func ValidateAliyunKeyTemplate(tmpl string) error {
+	if err := ValidateKeyTemplate(tmpl); err != nil {
+		return err
+	}
+	names := placeholders(tmpl)
+	if !names["backupId"] && !names["backupStartTime"] {
+		return fmt.Errorf("%w %q: must contain ${backupId} or ${backupStartTime}", ErrInvalidKeyTemplate, tmpl)
+	}
+	return nil
+}
+
+// placeholders 模板中引用的变量名
+func placeholders(tmpl string) map[string]bool {
+	names := make(map[string]bool)
+	os.Expand(tmpl, func(name string) string {
+		names[name] = true
+		return ""
+	})
+	return names
+}
+
+// usesEngine 模板是否引用了数据库引擎，引用时才需要额外查询实例信息
+func usesEngine(tmpl string) bool {
+	return strings.Contains(tmpl, "${engine}") || strings.Contains(tmpl, "$engine")
+}
+
+// aliyunKeyTemplate 实例模板优先，其次为阿里云全局模板，均未配置时使用默认模板
+func aliyunKeyTemplate(cfg *config.Config, instanceConfig config.InstanceConfig) string {
+	if instanceConfig.KeyTemplate != "" {
+		return instanceConfig.KeyTemplate
+	}
+	if cfg.RDS.Aliyun.KeyTemplate != "" {
+		return cfg.RDS.Aliyun.KeyTemplate
+	}
+	return DefaultAliyunKeyTemplate
+}
+
+// awsKeyTemplate 实例模板优先，其次为AWS全局模板，均未配置时使用 exporttask.s3prefix
+func awsKeyTemplate(cfg *config.Config, instanceConfig config.InstanceConfig) string {
+	if instanceConfig.KeyTemplate != "" {
+		return instanceConfig.KeyTemplate
+	}
+	if cfg.RDS.Aws.KeyTemplate != "" {
+		return cfg.RDS.Aws.KeyTemplate
+	}
+	return cfg.RDS.Aws.ExportTask.S3Prefix
+}
+
+// ValidateKeyTemplates 检查配置中的所有路径模板，未配置的阿里云模板使用默认模板，不需要检查
+func ValidateKeyTemplates(cfg *config.Config) error {
+	aliyunTemplates := map[string]string{
+		"rds.aliyun.keyTemplate": cfg.RDS.Aliyun.KeyTemplate,
+	}
+	for env, instanceConfig := range cfg.RDS.Aliyun.Instances {
+		aliyunTemplates["rds.aliyun.instances."+env+".keyTemplate"] = instanceConfig.KeyTemplate
+	}
+	for name, tmpl := range aliyunTemplates {
+		if tmpl == "" {
+			continue
+		}
+		if err := ValidateAliyunKeyTemplate(tmpl); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	templates := map[string]string{
+		"rds.aws.keyTemplate":         cfg.RDS.Aws.KeyTemplate,
+		"rds.aws.exporttask.s3prefix": cfg.RDS.Aws.ExportTask.S3Prefix,
+	}
+	for env, instanceConfig := range cfg.RDS.Aws.Instances {
+		templates["rds.aws.instances."+env+".keyTemplate"] = instanceConfig.KeyTemplate
+	}
+	for name, tmpl := range templates {
+		if err := ValidateKeyTemplate(tmpl); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRenderKey(t *testing.T) {
+	vars := KeyVars{
+		Env:        "prod",
+		InstanceID: "rm-bp1abc",
+		Region:     "cn-hangzhou",
+		BackupID:   "123456",
+		Engine:     "MySQL",
+		// 北京时间 2024-03-05 08:07:09，日期变量按 UTC 取值
+		BackupStartTime: time.Date(2024, 3, 5, 8, 7, 9, 0, time.FixedZone("CST", 8*3600)),
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default template",
+			tmpl: DefaultAliyunKeyTemplate,
+			want: "prod/backup-prod-20240305-000709-123456.xb",
+		},
+		{
+			name: "all placeholders",
+			tmpl: "${region}/${instanceId}/${engine}/${YYYY}/${MM}/${DD}/${hh}${mm}/${backupId}",
+			want: "cn-hangzhou/rm-bp1abc/MySQL/2024/03/05/0007/123456",
+		},
+		{
+			name: "short placeholder form",
+			tmpl: "$env/$backupId.xb",
+			want: "prod/123456.xb",
+		},
+		{
+			name: "leading and duplicate slashes are cleaned",
+			tmpl: "/backups//${env}/",
+			want: "backups/prod",
+		},
+		{
+			name: "empty template",
+			tmpl: "",
+			want: "",
+		},
+		{
+			name:    "unknown placeholder",
+			tmpl:    "${env}/${date}/${backupId}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderKey(tt.tmpl, vars)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKeyTemplate) {
+					t.Fatalf("RenderKey(%q) error = %v, want ErrInvalidKeyTemplate", tt.tmpl, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderKey(%q) error = %v", tt.tmpl, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderKey(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAliyunKeyTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{tmpl: DefaultAliyunKeyTemplate},
+		{tmpl: "${env}/${backupId}.xb"},
+		{tmpl: "${env}/${backupStartTime}.xb"},
+		{tmpl: "$env/$backupId.xb"},
+		{tmpl: "${env}/${YYYY}/${MM}/${DD}/backup.xb", wantErr: true},
+		{tmpl: "${env}/latest.xb", wantErr: true},
+		{tmpl: "${env}/${unknown}/${backupId}.xb", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			err := ValidateAliyunKeyTemplate(tt.tmpl)
+			if tt.wantErr && !errors.Is(err, ErrInvalidKeyTemplate) {
+				t.Errorf("ValidateAliyunKeyTemplate(%q) error = %v, want ErrInvalidKeyTemplate", tt.tmpl, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateAliyunKeyTemplate(%q) error = %v", tt.tmpl, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/metrics"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/store"
+	"time"
+)
+
+// LoadBackupMetrics 从导出历史中恢复各环境最新已导出备份的时间，服务重启后备份年龄指标不会中断
+func LoadBackupMetrics(cfg *config.Config) {
+	s := store.GetStore()
+	if s == nil {
+		return
+	}
+
+	records, err := s.ListExports(store.HistoryFilter{})
+	if err != nil {
+		logger.LogError("Failed to load export history for metrics",
+			logger.Error(err))
+		return
+	}
+	for _, r := range records {
+		if r.Outcome != store.OutcomeSucceeded && r.Outcome != store.OutcomeSkipped {
+			continue
+		}
+		if !configured(cfg, r.Provider, r.Env) {
+			continue
+		}
+		if t, ok := parseSourceTime(r.SourceTime); ok {
+			metrics.SetNewestBackup(r.Provider, r.Env, t)
+		}
+	}
+}
+
+// ForgetRemovedEnvs 配置重新加载后不再输出已移除环境的备份年龄
+func ForgetRemovedEnvs(old, cfg *config.Config) {
+	for env := range old.RDS.Aliyun.Instances {
+		if !configured(cfg, store.ProviderAliyun, env) {
+			metrics.DeleteNewestBackup(store.ProviderAliyun, env)
+		}
+	}
+	for env := range old.RDS.Aws.Instances {
+		if !configured(cfg, store.ProviderAws, env) {
+			metrics.DeleteNewestBackup(store.ProviderAws, env)
+		}
+	}
+}
+
+// configured 环境是否仍在配置中
+func configured(cfg *config.Config, provider, env string) bool {
+	var ok bool
+	switch provider {
+	case store.ProviderAliyun:
+		_, ok = cfg.RDS.Aliyun.Instances[env]
+	case store.ProviderAws:
+		_, ok = cfg.RDS.Aws.Instances[env]
+	}
+	return ok
+}
+
+// parseSourceTime 解析导出历史中 RFC3339 格式的备份时间
+func parseSourceTime(value string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil || t.IsZero() {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// observeAliyunExport 记录阿里云备份导出的耗时和结果，成功或已导出时更新最新备份时间
+func observeAliyunExport(env string, start time.Time, result *AliyunS3Result, err error) {
+	outcome := store.OutcomeSucceeded
+	switch {
+	case err != nil:
+		outcome = store.OutcomeFailed
+	case result.AlreadyExported:
+		outcome = store.OutcomeSkipped
+	}
+	metrics.ObserveExport(store.ProviderAliyun, env, outcome, time.Since(start))
+
+	if err == nil {
+		if t, ok := parseSourceTime(result.BackupStartTime); ok {
+			metrics.SetNewestBackup(store.ProviderAliyun, env, t)
+		}
+	}
+}
+
+// observeAwsExport 记录结束的快照导出任务在AWS侧的耗时，完成时更新最新备份时间为快照时间
+func observeAwsExport(record *store.ExportRecord, task *aws.ExportTask) {
+	elapsed := time.Duration(record.DurationSeconds * float64(time.Second))
+	if task.TaskStartTime != nil && task.TaskEndTime != nil {
+		elapsed = task.TaskEndTime.Sub(*task.TaskStartTime)
+	}
+	metrics.ObserveExport(store.ProviderAws, record.Env, record.Outcome, elapsed)
+
+	if record.Outcome == store.OutcomeSucceeded && task.SnapshotTime != nil {
+		metrics.SetNewestBackup(store.ProviderAws, record.Env, *task.SnapshotTime)
+	}
+}
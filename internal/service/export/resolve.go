@@ -0,0 +1,80 @@
+package export
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/service/source"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidProvider 不支持的云厂商
+var ErrInvalidProvider = errors.New("invalid provider")
+
+// PointInTimeBackup 目标时间点之前最新的成功备份
+type PointInTimeBackup struct {
+	Provider   string    `json:"provider"`
+	Env        string    `json:"env"`
+	TargetTime time.Time `json:"target_time"`
+	BackupID   string    `json:"backup_id"`
+	BackupTime time.Time `json:"backup_time"`
+	// DataLossWindow 使用该备份恢复时相对目标时间丢失的数据时长
+	DataLossWindow  string         `json:"data_loss_window"`
+	DataLossSeconds float64        `json:"data_loss_seconds"`
+	Backup          *source.Backup `json:"backup"`
+}
+
+// SourceFor 获取云厂商的备份来源和环境对应的实例配置
+func SourceFor(provider, env string) (source.BackupSource, config.InstanceConfig, error) {
+	src, err := source.Get(provider)
+	if err != nil {
+		return nil, config.InstanceConfig{}, ErrInvalidProvider
+	}
+	instanceConfig, ok := src.Instances(config.GetConfig())[env]
+	if !ok {
+		return nil, config.InstanceConfig{}, ErrInvalidEnv
+	}
+	return src, instanceConfig, nil
+}
+
+// ResolveBackupAt 查找指定环境在目标时间点或之前最新的成功备份/快照
+func ResolveBackupAt(ctx context.Context, provider, env string, target time.Time) (*PointInTimeBackup, error) {
+	src, instanceConfig, err := SourceFor(provider, env)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := src.ListBackups(ctx, instanceConfig, source.ListFilter{
+		End:           target,
+		AvailableOnly: true,
+	})
+	if err != nil {
+		return nil, &OpError{Op: "failed to list backups", Err: err}
+	}
+
+	var latest *source.Backup
+	for i := range backups {
+		b := &backups[i]
+		if !b.Available || b.RestoreTime.IsZero() || b.RestoreTime.After(target) {
+			continue
+		}
+		if latest == nil || b.RestoreTime.After(latest.RestoreTime) {
+			latest = b
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoBackup
+	}
+
+	lost := target.Sub(latest.RestoreTime)
+	return &PointInTimeBackup{
+		Provider:        provider,
+		Env:             env,
+		TargetTime:      target,
+		BackupID:        latest.ID,
+		BackupTime:      latest.RestoreTime,
+		DataLossWindow:  lost.String(),
+		DataLossSeconds: lost.Seconds(),
+		Backup:          latest,
+	}, nil
+}
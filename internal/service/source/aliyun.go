@@ -0,0 +1,188 @@
+package source
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/logger"
+	"backuprds/internal/service/aliyun"
+	"backuprds/internal/service/download"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+const (
+	aliyunStatusSuccess = "Success"
+	// intranetDialTimeout 检查内网下载地址能否连接的超时时间
+	intranetDialTimeout = 3 * time.Second
+)
+
+func init() {
+	Register(&aliyunSource{})
+}
+
+// aliyunSource 阿里云RDS物理/逻辑备份
+type aliyunSource struct{}
+
+func (s *aliyunSource) Name() string {
+	return store.ProviderAliyun
+}
+
+func (s *aliyunSource) Instances(cfg *config.Config) map[string]config.InstanceConfig {
+	return cfg.RDS.Aliyun.Instances
+}
+
+func (s *aliyunSource) ListBackups(ctx context.Context, instance config.InstanceConfig, filter ListFilter) ([]Backup, error) {
+	backupFilter := aliyun.BackupFilter{Start: filter.Start, End: filter.End}
+	if filter.AvailableOnly {
+		backupFilter.Status = aliyunStatusSuccess
+	}
+
+	backups, err := aliyun.ListBackups(ctx, instance.ID, aliyun.TargetFor(instance), backupFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Backup, 0, len(backups))
+	for i := range backups {
+		result = append(result, fromAliyun(instance.ID, &backups[i]))
+	}
+	return result, nil
+}
+
+func (s *aliyunSource) LatestBackup(ctx context.Context, instance config.InstanceConfig) (*Backup, error) {
+	backup, err := aliyun.GetLatestBackup(ctx, instance.ID, aliyun.TargetFor(instance))
+	if err != nil || backup == nil {
+		return nil, err
+	}
+	b := fromAliyun(instance.ID, backup)
+	return &b, nil
+}
+
+func (s *aliyunSource) GetBackup(ctx context.Context, instance config.InstanceConfig, id string) (*Backup, error) {
+	backup, err := aliyun.GetBackup(ctx, instance.ID, aliyun.TargetFor(instance), id)
+	if errors.Is(err, aliyun.ErrBackupNotFound) {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := fromAliyun(instance.ID, backup)
+	return &b, nil
+}
+
+// OpenBackup 下载备份文件，签名链接过期时通过 DescribeBackups 重新获取；
+// 服务与实例位于同一 region 时优先使用内网下载链接，内网下载失败时回退到公网链接
+func (s *aliyunSource) OpenBackup(ctx context.Context, instance config.InstanceConfig, backup *Backup, opts download.Options) (download.File, error) {
+	if backup.DownloadURL == "" {
+		return nil, ErrStreamNotSupported
+	}
+
+	if backup.IntranetDownloadURL != "" && aliyun.SameRegion(instance.Region) {
+		file, err := openIntranet(ctx, backup.IntranetDownloadURL, withRefresh(ctx, opts, instance, backup, true))
+		if err == nil {
+			logger.LogInfo("Downloading backup over intranet",
+				logger.String("instance_id", instance.ID),
+				logger.String("backup_id", backup.ID),
+				logger.String("region", instance.Region))
+			return file, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.LogWarn("Failed to download backup over intranet, falling back to public URL",
+			logger.String("instance_id", instance.ID),
+			logger.String("backup_id", backup.ID),
+			logger.Error(err))
+	}
+	return download.Open(ctx, backup.DownloadURL, withRefresh(ctx, opts, instance, backup, false))
+}
+
+// openIntranet 先确认内网地址可以连接，避免内网不通时在下载重试上耗费时间
+func openIntranet(ctx context.Context, rawURL string, opts download.Options) (download.File, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	dialer := net.Dialer{Timeout: intranetDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+
+	return download.Open(ctx, rawURL, opts)
+}
+
+// withRefresh 未设置 RefreshURL 时通过 DescribeBackups 重新获取内网或公网下载链接
+func withRefresh(ctx context.Context, opts download.Options, instance config.InstanceConfig, backup *Backup, intranet bool) download.Options {
+	if opts.RefreshURL != nil {
+		return opts
+	}
+	opts.RefreshURL = func() (string, error) {
+		refreshed, err := aliyun.GetBackup(ctx, instance.ID, aliyun.TargetFor(instance), backup.ID)
+		if err != nil {
+			return "", err
+		}
+		downloadURL := refreshed.DownloadURL
+		if intranet {
+			downloadURL = refreshed.IntranetDownloadURL
+		}
+		if downloadURL == "" {
+			return "", errors.New("backup has no download URL")
+		}
+		return downloadURL, nil
+	}
+	return opts
+}
+
+func (s *aliyunSource) DescribeInstance(ctx context.Context, instance config.InstanceConfig) (*Instance, error) {
+	detail, err := aliyun.GetInstance(ctx, instance.ID, aliyun.TargetFor(instance))
+	if err != nil {
+		return nil, err
+	}
+	region := detail.RegionID
+	if region == "" {
+		region = instance.Region
+	}
+	return &Instance{
+		ID:            instance.ID,
+		Provider:      s.Name(),
+		Region:        region,
+		Engine:        detail.Engine,
+		EngineVersion: detail.EngineVersion,
+		Status:        detail.Status,
+		Detail:        detail,
+	}, nil
+}
+
+func fromAliyun(instanceID string, backup *aliyun.Backup) Backup {
+	b := Backup{
+		ID:                  backup.BackupID,
+		Provider:            store.ProviderAliyun,
+		InstanceID:          instanceID,
+		Type:                backup.Method,
+		Status:              backup.Status,
+		Available:           backup.Status == aliyunStatusSuccess,
+		Size:                backup.Size,
+		DownloadURL:         backup.DownloadURL,
+		IntranetDownloadURL: backup.IntranetDownloadURL,
+		Detail:              backup,
+	}
+	if t, err := time.Parse(time.RFC3339, backup.StartTime); err == nil {
+		b.StartTime = t
+	}
+	if t, err := backup.RestoreTime(); err == nil {
+		b.RestoreTime = t
+	}
+	return b
+}
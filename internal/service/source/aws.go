@@ -0,0 +1,103 @@
+package source
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/service/aws"
+	"backuprds/internal/service/download"
+	"backuprds/internal/store"
+	"context"
+	"errors"
+)
+
+func init() {
+	Register(&awsSource{})
+}
+
+// awsSource AWS RDS快照，快照数据只能通过导出任务写入S3，不能直接下载
+type awsSource struct{}
+
+func (s *awsSource) Name() string {
+	return store.ProviderAws
+}
+
+func (s *awsSource) Instances(cfg *config.Config) map[string]config.InstanceConfig {
+	return cfg.RDS.Aws.Instances
+}
+
+func (s *awsSource) ListBackups(ctx context.Context, instance config.InstanceConfig, filter ListFilter) ([]Backup, error) {
+	snapshotFilter := aws.SnapshotFilter{Start: filter.Start, End: filter.End}
+	if filter.AvailableOnly {
+		snapshotFilter.Status = aws.SnapshotStatusAvailable
+	}
+
+	snapshots, err := aws.ListSnapshots(ctx, instance.ID, aws.TargetFor(instance), snapshotFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Backup, 0, len(snapshots))
+	for i := range snapshots {
+		result = append(result, fromAws(&snapshots[i]))
+	}
+	return result, nil
+}
+
+func (s *awsSource) LatestBackup(ctx context.Context, instance config.InstanceConfig) (*Backup, error) {
+	snapshot, err := aws.GetLatestSnapshot(ctx, instance.ID, aws.TargetFor(instance))
+	if err != nil || snapshot == nil {
+		return nil, err
+	}
+	b := fromAws(snapshot)
+	return &b, nil
+}
+
+// GetBackup 根据快照标识符或ARN获取快照
+func (s *awsSource) GetBackup(ctx context.Context, instance config.InstanceConfig, id string) (*Backup, error) {
+	snapshot, err := aws.GetSnapshot(ctx, instance.ID, aws.TargetFor(instance), id)
+	if errors.Is(err, aws.ErrSnapshotNotFound) {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := fromAws(snapshot)
+	return &b, nil
+}
+
+func (s *awsSource) OpenBackup(ctx context.Context, instance config.InstanceConfig, backup *Backup, opts download.Options) (download.File, error) {
+	return nil, ErrStreamNotSupported
+}
+
+func (s *awsSource) DescribeInstance(ctx context.Context, instance config.InstanceConfig) (*Instance, error) {
+	detail, err := aws.GetInstance(ctx, instance.ID, aws.TargetFor(instance))
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{
+		ID:            instance.ID,
+		Provider:      s.Name(),
+		Region:        instance.Region,
+		Engine:        detail.Engine,
+		EngineVersion: detail.EngineVersion,
+		Status:        detail.Status,
+		Detail:        detail,
+	}, nil
+}
+
+func fromAws(snapshot *aws.Snapshot) Backup {
+	b := Backup{
+		ID:         snapshot.SnapshotID,
+		Arn:        snapshot.SnapshotArn,
+		Provider:   store.ProviderAws,
+		InstanceID: snapshot.InstanceID,
+		Type:       snapshot.Type,
+		Status:     snapshot.Status,
+		Available:  snapshot.Status == aws.SnapshotStatusAvailable,
+		Detail:     snapshot,
+	}
+	if snapshot.CreateTime != nil {
+		b.StartTime = *snapshot.CreateTime
+		b.RestoreTime = *snapshot.CreateTime
+	}
+	return b
+}
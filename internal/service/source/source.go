@@ -0,0 +1,117 @@
+// Package source 定义备份来源接口，各云厂商的实现按名称注册
+package source
+
+import (
+	"backuprds/internal/config"
+	"backuprds/internal/service/download"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownProvider 未注册的云厂商
+	ErrUnknownProvider = errors.New("unknown provider")
+	// ErrBackupNotFound 指定的备份不存在
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrStreamNotSupported 备份不能直接下载，例如AWS快照只能通过导出任务写入S3
+	ErrStreamNotSupported = errors.New("backup cannot be streamed from this provider")
+)
+
+// Backup 各云厂商统一的备份信息
+type Backup struct {
+	ID string `json:"id"`
+	// Arn AWS快照ARN，阿里云备份为空
+	Arn        string `json:"arn,omitempty"`
+	Provider   string `json:"provider"`
+	InstanceID string `json:"instance_id"`
+	// Type 阿里云为备份方式，AWS为快照类型
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Available 备份是否成功且可用于恢复或导出
+	Available bool  `json:"available"`
+	Size      int64 `json:"size"`
+	// StartTime 阿里云为备份开始时间，AWS为快照创建时间
+	StartTime time.Time `json:"start_time"`
+	// RestoreTime 使用该备份恢复后数据所处的时间点
+	RestoreTime         time.Time `json:"restore_time"`
+	DownloadURL         string    `json:"download_url,omitempty"`
+	IntranetDownloadURL string    `json:"intranet_download_url,omitempty"`
+	// Detail 云厂商原始的备份信息
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+// Instance 各云厂商统一的实例信息
+type Instance struct {
+	ID            string      `json:"id"`
+	Provider      string      `json:"provider"`
+	Region        string      `json:"region"`
+	Engine        string      `json:"engine"`
+	EngineVersion string      `json:"engine_version"`
+	Status        string      `json:"status"`
+	Detail        interface{} `json:"detail,omitempty"`
+}
+
+// ListFilter 备份查询条件，零值字段不参与过滤
+type ListFilter struct {
+	Start time.Time
+	End   time.Time
+	// AvailableOnly 只返回成功且可用的备份
+	AvailableOnly bool
+}
+
+// BackupSource 备份来源
+type BackupSource interface {
+	// Name 云厂商名称，用作注册表的键
+	Name() string
+	// Instances 配置中该云厂商的实例，键为环境名称
+	Instances(cfg *config.Config) map[string]config.InstanceConfig
+	// ListBackups 查询实例的备份，按开始时间倒序返回
+	ListBackups(ctx context.Context, instance config.InstanceConfig, filter ListFilter) ([]Backup, error)
+	// LatestBackup 获取最新的备份，没有备份时返回 nil
+	LatestBackup(ctx context.Context, instance config.InstanceConfig) (*Backup, error)
+	// GetBackup 根据备份ID获取备份，不存在时返回 ErrBackupNotFound
+	GetBackup(ctx context.Context, instance config.InstanceConfig, id string) (*Backup, error)
+	// OpenBackup 打开备份文件的下载流，不支持时返回 ErrStreamNotSupported，ctx 取消时中断下载
+	OpenBackup(ctx context.Context, instance config.InstanceConfig, backup *Backup, opts download.Options) (download.File, error)
+	// DescribeInstance 查询实例信息
+	DescribeInstance(ctx context.Context, instance config.InstanceConfig) (*Instance, error)
+}
+
+var (
+	mu      sync.RWMutex
+	sources = make(map[string]BackupSource)
+)
+
+// Register 注册备份来源，同名的来源会被替换
+func Register(s BackupSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	sources[s.Name()] = s
+}
+
+// Get 根据云厂商名称获取备份来源
+func Get(provider string) (BackupSource, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := sources[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+	}
+	return s, nil
+}
+
+// Providers 已注册的云厂商名称
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
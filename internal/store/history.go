@@ -0,0 +1,149 @@
+package store
+
+import (
+	"backuprds/internal/jobs"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 导出结果
+const (
+	OutcomeStarted   = "started"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	// OutcomeSkipped 备份已导出过，本次未重复上传
+	OutcomeSkipped = "skipped"
+)
+
+// 云厂商
+const (
+	ProviderAliyun = "aliyun"
+	ProviderAws    = "aws"
+)
+
+// ExportRecord 一次导出尝试的历史记录
+type ExportRecord struct {
+	ID              string     `json:"id"`
+	Env             string     `json:"env"`
+	Provider        string     `json:"provider"`
+	JobID           string     `json:"job_id,omitempty"`
+	SourceID        string     `json:"source_id,omitempty"`
+	SourceTime      string     `json:"source_time,omitempty"`
+	Bucket          string     `json:"bucket,omitempty"`
+	Key             string     `json:"key,omitempty"`
+	ExportTaskID    string     `json:"export_task_id,omitempty"`
+	Bytes           int64      `json:"bytes"`
+	SHA256          string     `json:"sha256,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Outcome         string     `json:"outcome"`
+	Error           string     `json:"error,omitempty"`
+
+	// Destinations 阿里云备份各存储目标的上传结果
+	Destinations []jobs.DestinationResult `json:"destinations,omitempty"`
+}
+
+// HistoryFilter 历史查询条件，零值字段不参与过滤
+type HistoryFilter struct {
+	Env          string
+	Provider     string
+	Outcome      string
+	ExportTaskID string
+	Since        time.Time
+	Limit        int
+}
+
+// NewExportRecord 创建一条开始状态的导出记录，ID 按开始时间排序
+func NewExportRecord(provider, env string) (*ExportRecord, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate record id: %v", err)
+	}
+
+	now := time.Now()
+	return &ExportRecord{
+		ID:        fmt.Sprintf("%020d-%s", now.UnixNano(), hex.EncodeToString(b)),
+		Env:       env,
+		Provider:  provider,
+		StartedAt: now,
+		Outcome:   OutcomeStarted,
+	}, nil
+}
+
+// Finish 记录导出结束时间、耗时和结果
+func (r *ExportRecord) Finish(err error) {
+	now := time.Now()
+	r.FinishedAt = &now
+	r.DurationSeconds = now.Sub(r.StartedAt).Seconds()
+	if err != nil {
+		r.Outcome = OutcomeFailed
+		r.Error = err.Error()
+	} else {
+		r.Outcome = OutcomeSucceeded
+		r.Error = ""
+	}
+}
+
+// SaveExport 写入或更新导出记录
+func (s *Store) SaveExport(r *ExportRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export record: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHistory).Put([]byte(r.ID), data)
+	})
+}
+
+// ListExports 按条件查询导出记录，从最新的记录开始倒序遍历，达到 Limit 条或早于 Since 时停止
+func (s *Store) ListExports(filter HistoryFilter) ([]*ExportRecord, error) {
+	var records []*ExportRecord
+
+	var since []byte
+	if !filter.Since.IsZero() {
+		since = []byte(fmt.Sprintf("%020d", filter.Since.UnixNano()))
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketHistory).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if since != nil && bytes.Compare(k, since) < 0 {
+				break
+			}
+
+			var r ExportRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("failed to unmarshal export record %s: %v", k, err)
+			}
+			if filter.Env != "" && r.Env != filter.Env {
+				continue
+			}
+			if filter.Provider != "" && r.Provider != filter.Provider {
+				continue
+			}
+			if filter.Outcome != "" && r.Outcome != filter.Outcome {
+				continue
+			}
+			if filter.ExportTaskID != "" && r.ExportTaskID != filter.ExportTaskID {
+				continue
+			}
+			records = append(records, &r)
+			if filter.Limit > 0 && len(records) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"backuprds/internal/jobs"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SaveJob 写入或更新任务记录，实现 jobs.Persister
+func (s *Store) SaveJob(job *jobs.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketJobs).Put([]byte(job.ID), data)
+	})
+}
+
+// GetJob 查询任务记录，不存在时返回 nil
+func (s *Store) GetJob(id string) (*jobs.Job, error) {
+	var job *jobs.Job
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketJobs).Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		job = &jobs.Job{}
+		return json.Unmarshal(v, job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job %s: %v", id, err)
+	}
+	return job, nil
+}
+
+// ListJobs 返回所有任务记录
+func (s *Store) ListJobs() ([]*jobs.Job, error) {
+	var list []*jobs.Job
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketJobs).ForEach(func(k, v []byte) error {
+			var job jobs.Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("failed to unmarshal job %s: %v", k, err)
+			}
+			list = append(list, &job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// PruneJobs 只保留最近结束的 keep 个任务记录，未结束的任务不删除，返回删除的数量
+func (s *Store) PruneJobs(keep int) (int, error) {
+	type finished struct {
+		id string
+		at time.Time
+	}
+
+	var deleted int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketJobs)
+
+		var list []finished
+		err := b.ForEach(func(k, v []byte) error {
+			var job jobs.Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("failed to unmarshal job %s: %v", k, err)
+			}
+			if job.Finished() && job.FinishedAt != nil {
+				list = append(list, finished{id: job.ID, at: *job.FinishedAt})
+			}
+			return nil
+		})
+		if err != nil || len(list) <= keep {
+			return err
+		}
+
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].at.After(list[j].at)
+		})
+		for _, f := range list[keep:] {
+			if err := b.Delete([]byte(f.id)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %v", err)
+	}
+	return deleted, nil
+}
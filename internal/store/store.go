@@ -0,0 +1,80 @@
+// Package store 基于 bbolt 的嵌入式存储，持久化导出历史和后台任务记录
+package store
+
+import (
+	"backuprds/internal/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultPath 未配置存储路径时使用的数据库文件
+const DefaultPath = "data/backuprds.db"
+
+var (
+	bucketHistory = []byte("history")
+	bucketJobs    = []byte("jobs")
+)
+
+// Store 嵌入式存储
+type Store struct {
+	db *bolt.DB
+}
+
+var global *Store
+
+// Init 打开全局存储
+func Init(path string) error {
+	s, err := Open(path)
+	if err != nil {
+		return err
+	}
+	global = s
+	return nil
+}
+
+// GetStore 返回全局存储，未初始化时返回 nil
+func GetStore() *Store {
+	return global
+}
+
+// Open 打开(必要时创建)数据库文件
+func Open(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketHistory, bucketJobs} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize store buckets: %v", err)
+	}
+
+	logger.LogInfo("Store opened",
+		logger.String("path", path))
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
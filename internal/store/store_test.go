@@ -0,0 +1,145 @@
+package store
+
+import (
+	"backuprds/internal/jobs"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestListExports(t *testing.T) {
+	s := openTestStore(t)
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// 按开始时间依次写入 r0..r5，ID 与 NewExportRecord 的格式相同
+	seed := []struct {
+		env      string
+		provider string
+		outcome  string
+	}{
+		{"prod", ProviderAliyun, OutcomeSucceeded},
+		{"prod", ProviderAws, OutcomeStarted},
+		{"staging", ProviderAliyun, OutcomeFailed},
+		{"prod", ProviderAliyun, OutcomeSkipped},
+		{"staging", ProviderAliyun, OutcomeSucceeded},
+		{"prod", ProviderAliyun, OutcomeSucceeded},
+	}
+	// 乱序写入，查询结果仍按开始时间倒序
+	for _, i := range []int{3, 0, 5, 1, 4, 2} {
+		startedAt := base.Add(time.Duration(i) * time.Hour)
+		r := &ExportRecord{
+			ID:        fmt.Sprintf("%020d-%08x", startedAt.UnixNano(), i),
+			Env:       seed[i].env,
+			Provider:  seed[i].provider,
+			Outcome:   seed[i].outcome,
+			SourceID:  fmt.Sprintf("r%d", i),
+			StartedAt: startedAt,
+		}
+		if err := s.SaveExport(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []string
+	}{
+		{"all newest first", HistoryFilter{}, []string{"r5", "r4", "r3", "r2", "r1", "r0"}},
+		{"limit", HistoryFilter{Limit: 2}, []string{"r5", "r4"}},
+		{"since", HistoryFilter{Since: base.Add(3 * time.Hour)}, []string{"r5", "r4", "r3"}},
+		{"since between records", HistoryFilter{Since: base.Add(90 * time.Minute)}, []string{"r5", "r4", "r3", "r2"}},
+		{"env and provider", HistoryFilter{Env: "prod", Provider: ProviderAliyun}, []string{"r5", "r3", "r0"}},
+		{"limit counts matching records", HistoryFilter{Env: "staging", Limit: 1}, []string{"r4"}},
+		{"outcome", HistoryFilter{Outcome: OutcomeStarted}, []string{"r1"}},
+		{"since and limit", HistoryFilter{Env: "prod", Since: base.Add(time.Hour), Limit: 5}, []string{"r5", "r3", "r1"}},
+		{"no match", HistoryFilter{Env: "dev"}, nil},
+		{"since after all records", HistoryFilter{Since: base.Add(24 * time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := s.ListExports(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.SourceID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListExports(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneJobs(t *testing.T) {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	finishedAt := func(hours int) *time.Time {
+		at := base.Add(time.Duration(hours) * time.Hour)
+		return &at
+	}
+	seed := []*jobs.Job{
+		{ID: "queued", State: jobs.StateQueued},
+		{ID: "uploading", State: jobs.StateUploading},
+		{ID: "done-1", State: jobs.StateSucceeded, FinishedAt: finishedAt(1)},
+		{ID: "done-3", State: jobs.StateFailed, FinishedAt: finishedAt(3)},
+		{ID: "done-2", State: jobs.StateSucceeded, FinishedAt: finishedAt(2)},
+		{ID: "done-4", State: jobs.StateSucceeded, FinishedAt: finishedAt(4)},
+	}
+
+	tests := []struct {
+		name        string
+		keep        int
+		wantDeleted int
+		want        []string
+	}{
+		{"keep newest finished", 2, 2, []string{"done-3", "done-4", "queued", "uploading"}},
+		{"keep none keeps unfinished", 0, 4, []string{"queued", "uploading"}},
+		{"fewer than keep", 10, 0, []string{"done-1", "done-2", "done-3", "done-4", "queued", "uploading"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t)
+			for _, job := range seed {
+				if err := s.SaveJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deleted, err := s.PruneJobs(tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("PruneJobs(%d) deleted %d, want %d", tt.keep, deleted, tt.wantDeleted)
+			}
+
+			list, err := s.ListJobs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, job := range list {
+				got = append(got, job.ID)
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("remaining jobs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
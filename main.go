@@ -21,7 +21,7 @@ func main() {
 	}
 
 	if err := cmd.Execute(); err != nil {
-		logger.LogError("Failed to execute command", logger.Error(err))
-		os.Exit(1)
+		// cobra 已输出错误信息
+		os.Exit(cmd.ExitCode(err))
 	}
 }
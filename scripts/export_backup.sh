@@ -1,5 +1,7 @@
 #!/bin/bash
 
+# 已由服务内置的定时导出 (config.yaml 中的 schedules) 取代，仅保留用于手动补导出
+
 API_HOST="http://13.236.126.165:18888"
 S3_CONSOLE_URL="https://s3.console.aws.amazon.com/s3/buckets"
 
@@ -112,6 +114,35 @@ EOF
     curl -s -X POST -H "Content-Type: application/json" -d "$payload" "$WEBHOOK_URL"
 }
 
+# 轮询后台任务直到结束，成功返回 0 并输出任务 JSON
+wait_for_job() {
+    local job_id=$1
+    local timeout=${JOB_TIMEOUT:-21600}
+    local interval=30
+    local elapsed=0
+    local job state
+
+    while [ $elapsed -lt $timeout ]; do
+        job=$(curl -s "${API_HOST}/jobs/${job_id}")
+        state=$(echo "$job" | jq -r '.state')
+        case "$state" in
+            succeeded)
+                echo "$job"
+                return 0
+                ;;
+            failed)
+                echo "$job"
+                return 1
+                ;;
+        esac
+        sleep $interval
+        elapsed=$((elapsed + interval))
+    done
+
+    echo "{\"error\": \"job ${job_id} timed out after ${timeout}s\"}"
+    return 1
+}
+
 export_aliyun_backup() {
     local env=$1
     local failed=0
@@ -124,10 +155,22 @@ export_aliyun_backup() {
         response=$(curl -s -w "%{http_code}" -X POST "${API_HOST}/alirds/export/s3/${env}")
         http_code=${response: -3}
         response=${response:0:-3}
+
+        # 导出接口返回 202 和任务 ID，轮询任务状态直到结束
+        if [ "$http_code" -eq 202 ]; then
+            local job_id=$(echo "$response" | jq -r '.job_id')
+            log "${env} 环境导出任务已提交 (任务ID: ${job_id})"
+            if response=$(wait_for_job "$job_id"); then
+                http_code=200
+            else
+                http_code=500
+            fi
+        fi
         
         if check_response "$response" "$http_code" "阿里云 RDS -> S3"; then
-            local download_url=$(echo $response | jq -r '.data.backup_download_url')
-            ALIYUN_RESULTS="${ALIYUN_RESULTS}\n- ✅ ${env}: [查看备份文件](${download_url})"
+            local s3_key=$(echo "$response" | jq -r '.s3_key')
+            local s3_link="${S3_CONSOLE_URL}/${ALIYUN_BUCKET}?prefix=${s3_key}"
+            ALIYUN_RESULTS="${ALIYUN_RESULTS}\n- ✅ ${env}: [查看备份文件](${s3_link})"
             log "✅ ${env} 环境备份导出成功"
             return 0
         else
@@ -185,11 +185,15 @@ function BackupList() {
             });
             const data = await response.json();
             
-            if (!data.error) {
-                message.success('导出任务已启动');
-                message.info(`备份将上传至 ${data.s3_bucket} (${data.region})`);
+            if (data.error) {
+                message.error('导出失败：' + data.error);
+            } else if (data.already_exported) {
+                // 备份已导出且校验通过，服务端不会创建任务，响应中没有任务ID
+                message.success('备份已导出，无需重复上传');
+                message.info(`备份已位于 ${data.s3_bucket} (${data.region})：${data.s3_key}`);
             } else {
-                message.error('导出失败���' + data.error);
+                message.success('导出任务已启动');
+                message.info(`备份将上传至 ${data.s3_bucket} (${data.region})，任务ID：${data.job_id}`);
             }
         } catch (error) {
             console.error('导出到S3出错：', error);